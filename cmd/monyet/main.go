@@ -1,24 +1,216 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"monyet/internal/monyet"
 )
 
+const usage = `Usage:
+  monyet <script.nyet> [args...]
+  monyet run [--set key=value]... <script.nyet> [args...]
+  monyet check <script.nyet>
+  monyet cert [--host name]... [--days n] [--out dir] [--force]`
+
 func main() {
-	src, _ := os.ReadFile(os.Args[1])
-	absPath, _ := filepath.Abs(os.Args[1])
+	if len(os.Args) < 2 {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "check":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: monyet check <script.nyet>")
+			os.Exit(2)
+		}
+		os.Exit(runCheck(os.Args[2]))
+	case "cert":
+		os.Exit(runCert(os.Args[2:]))
+	case "run":
+		sets, rest, err := parseRunFlags(os.Args[2:])
+		if err != nil || len(rest) == 0 {
+			if err != nil {
+				fmt.Println(err)
+			}
+			fmt.Println(usage)
+			os.Exit(2)
+		}
+		runScript(rest, sets)
+	default:
+		runScript(os.Args[1:], nil)
+	}
+}
+
+// parseRunFlags mengambil --set key=value (boleh berulang) sebelum path
+// script. Argumen setelah path script diteruskan apa adanya ke $argv.
+func parseRunFlags(args []string) (sets, rest []string, err error) {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--set":
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("--set butuh key=value")
+			}
+			i++
+			sets = append(sets, args[i])
+		case strings.HasPrefix(a, "--set="):
+			sets = append(sets, strings.TrimPrefix(a, "--set="))
+		case strings.HasPrefix(a, "-"):
+			return nil, nil, fmt.Errorf("flag tidak dikenal: %s", a)
+		default:
+			return sets, args[i:], nil
+		}
+	}
+	return sets, nil, nil
+}
+
+// runScript menjalankan args[0]; sisa args menjadi $argv.
+func runScript(args []string, sets []string) {
+	src, _ := os.ReadFile(args[0])
+	absPath, _ := filepath.Abs(args[0])
 	baseDir := filepath.Dir(absPath)
 
+	cfg, err := monyet.LoadConfig(baseDir, sets)
+	if err != nil {
+		fmt.Printf("Config error: %v\n", err)
+		os.Exit(1)
+	}
+
 	lexer := monyet.NewLexer(string(src))
 	parser := monyet.NewParser(lexer)
 	prog := parser.Parse()
 
 	env := monyet.NewEnv()
 	env.SetVar("__BASE_DIR__", baseDir)
+	env.SetConfig(cfg)
+	monyet.SetScriptGlobals(env, args)
+	// Fungsi file dibatasi ke direktori script, kecuali root-nya diganti
+	// lewat config fs_root (misal MONYET_FS_ROOT)
+	if v, ok := cfg.Setting("fs_root"); ok && fmt.Sprint(v) != "" {
+		root := fmt.Sprint(v)
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(baseDir, root)
+		}
+		env.SetVar("__FS_ROOT__", root)
+	}
 	// fmt.Printf("DEBUG: Berhasil parse %d statement\n", len(prog.Statements))
 	// fmt.Printf("Parsed statements: %d\n", len(prog.Statements))
+	defer func() {
+		if r := recover(); r != nil {
+			if exit, ok := r.(monyet.ExitSignal); ok {
+				monyet.Shutdown(env)
+				os.Exit(exit.Code)
+			}
+			panic(r)
+		}
+	}()
 	monyet.Eval(prog, env)
+	// Callback on_shutdown() dan penutupan database untuk script biasa
+	monyet.Shutdown(env)
+}
+
+// runCheck menjalankan pengecekan statis tanpa mengeksekusi script.
+func runCheck(path string) (code int) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Printf("%s: %v\n", path, err)
+		return 1
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("%s: parse error: %v\n", path, r)
+			code = 1
+		}
+	}()
+	prog := monyet.NewParser(monyet.NewLexer(string(src))).Parse()
+
+	errs := monyet.Check(prog, path)
+	for _, e := range errs {
+		fmt.Println(e)
+	}
+	if len(errs) > 0 {
+		return 1
+	}
+	fmt.Printf("%s: OK\n", path)
+	return 0
+}
+
+// runCert membuat cert.pem dan key.pem self-signed untuk development,
+// berlaku untuk localhost, 127.0.0.1 dan ::1 kecuali --host diberikan.
+func runCert(args []string) int {
+	var hosts []string
+	days, out, force := 365, ".", false
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		name, val, hasVal := strings.Cut(a, "=")
+		if (name == "--host" || name == "--days" || name == "--out") && !hasVal {
+			if i+1 >= len(args) {
+				fmt.Printf("%s butuh nilai\n", name)
+				return 2
+			}
+			i++
+			val = args[i]
+		}
+		switch name {
+		case "--host":
+			for _, h := range strings.Split(val, ",") {
+				if h = strings.TrimSpace(h); h != "" {
+					hosts = append(hosts, h)
+				}
+			}
+		case "--days":
+			n, err := strconv.Atoi(val)
+			if err != nil || n <= 0 {
+				fmt.Printf("--days harus angka positif, dapat %q\n", val)
+				return 2
+			}
+			days = n
+		case "--out":
+			out = val
+		case "--force":
+			force = true
+		default:
+			fmt.Printf("flag tidak dikenal: %s\n", a)
+			fmt.Println(usage)
+			return 2
+		}
+	}
+	if len(hosts) == 0 {
+		hosts = []string{"localhost", "127.0.0.1", "::1"}
+	}
+
+	certPath, keyPath := filepath.Join(out, "cert.pem"), filepath.Join(out, "key.pem")
+	if !force {
+		for _, p := range []string{certPath, keyPath} {
+			if _, err := os.Stat(p); err == nil {
+				fmt.Printf("%s sudah ada, pakai --force untuk menimpa\n", p)
+				return 1
+			}
+		}
+	}
+
+	certPEM, keyPEM, err := monyet.GenerateCert(hosts, time.Duration(days)*24*time.Hour)
+	if err == nil {
+		err = os.MkdirAll(out, 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(certPath, certPEM, 0644)
+	}
+	if err == nil {
+		err = os.WriteFile(keyPath, keyPEM, 0600)
+	}
+	if err != nil {
+		fmt.Printf("Gagal membuat sertifikat: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Sertifikat self-signed untuk %s (berlaku %d hari):\n  %s\n  %s\n", strings.Join(hosts, ", "), days, certPath, keyPath)
+	fmt.Println("Hanya untuk development. Pakai di script: serve_tls(8443, null, \"cert.pem\", \"key.pem\");")
+	return 0
 }
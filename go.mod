@@ -1,3 +1,5 @@
 module monyet
 
 go 1.25.4
+
+require golang.org/x/crypto v0.54.0
@@ -14,6 +14,12 @@ type Variable struct {
 	Name string
 }
 
+// Identifier adalah nama tanpa $: konstanta (PHP_EOL), superglobal gaya lama
+// (_GET["a"]) atau nama function yang dipakai sebagai callable.
+type Identifier struct {
+	Name string
+}
+
 type Binary struct {
 	Left  Node
 	Op    TokenType
@@ -23,6 +29,7 @@ type Binary struct {
 type Assign struct {
 	Name  string
 	Value Node
+	Type  string // anotasi tipe opsional: int $a = 1;
 }
 
 type Echo struct {
@@ -34,9 +41,17 @@ type String struct {
 }
 
 type Function struct {
-	Name   string
-	Params []string
-	Body   []Node
+	Name       string
+	Params     []string
+	ParamTypes []string // "" berarti tanpa anotasi
+	ReturnType string
+	Body       []Node
+	Generator  bool // true kalau body berisi yield
+}
+
+type ConstDecl struct {
+	Name  string
+	Value Node
 }
 
 type Call struct {
@@ -44,10 +59,28 @@ type Call struct {
 	Args []Node
 }
 
+// FunctionLiteral adalah function anonim yang dipakai sebagai nilai:
+// $f = function ($x) { return $x * 2; }; atau fn($x) => $x * 2
+type FunctionLiteral struct {
+	Fn Function
+}
+
+// DynamicCall memanggil callable yang disimpan di variabel: $f(1, 2)
+type DynamicCall struct {
+	Callee Node
+	Args   []Node
+}
+
 type Return struct {
 	Value Node
 }
 
+// Yield mengirim satu nilai dari generator: yield $v; atau yield $k => $v;
+type Yield struct {
+	Key   Node // nil berarti key otomatis 0, 1, 2, ...
+	Value Node
+}
+
 type If struct {
 	Condition Node
 	Then      []Node
@@ -56,7 +89,10 @@ type If struct {
 
 type Serve struct {
 	Port    Node
-	Handler string
+	Handler string // kosong kalau serve(port) memakai route()
+	Options Node   // array opsi server, nil kalau tidak ada
+	Cert    Node   // serve_tls: path sertifikat dan private key
+	Key     Node
 }
 
 type IndexAccess struct {
@@ -68,26 +104,30 @@ type Include struct {
 	Path string
 }
 
-type Render struct {
-	Path Node
+type MapLiteral struct {
+	Pairs map[Node]Node
 }
-
-type JsonEncode struct {
-	Data Node
+type ForeachStatement struct {
+	Iterable     Node
+	Key          string
+	Value        string
+	ValuePattern *ListPattern // foreach ($rows as [$a, $b]) ; Value kosong
+	Body         []Node
 }
 
-type JsonDecode struct {
-	Value Node
+// ListPattern adalah target destructuring: [$a, $b] atau ["nama" => $n].
+type ListPattern struct {
+	Items []PatternItem
 }
 
-type MapLiteral struct {
-	Pairs map[Node]Node
+type PatternItem struct {
+	Key    Node // Number untuk posisi, atau ekspresi key
+	Target Node // Variable atau ListPattern (nested)
 }
-type ForeachStatement struct {
-	Iterable Node
-	Key      string
-	Value    string
-	Body     []Node
+
+type DestructureAssign struct {
+	Pattern ListPattern
+	Value   Node
 }
 
 type IndexAssign struct {
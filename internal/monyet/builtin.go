@@ -0,0 +1,217 @@
+package monyet
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// BuiltinFunc adalah implementasi Go dari fungsi bawaan. Argumen sudah
+// dievaluasi dan sudah dicek jumlah serta tipenya sebelum Fn dipanggil.
+type BuiltinFunc func(env *Env, args []interface{}) interface{}
+
+// Builtin adalah fungsi bawaan beserta metadata yang dipakai saat runtime
+// dan oleh `monyet check`.
+type Builtin struct {
+	Name    string
+	MinArgs int
+	MaxArgs int      // -1 berarti variadic
+	Params  []string // tipe tiap argumen, "" berarti bebas
+	Returns string   // tipe hasil untuk checker, "" berarti tidak diketahui
+	Refs    []int    // index argumen yang dikirim by-reference sebagai *Ref
+	Fn      BuiltinFunc
+}
+
+// Ref adalah argumen by-reference, dipakai builtin yang mengubah variabel
+// milik pemanggil seperti array_push($list, 1) atau usort($list, $cmp).
+type Ref struct {
+	env  *Env
+	name string
+}
+
+func newRef(n Node, env *Env, fnName string, idx int) *Ref {
+	v, ok := n.(Variable)
+	if !ok {
+		panic(fmt.Sprintf("argumen ke-%d %s() harus berupa variabel", idx+1, fnName))
+	}
+	return &Ref{env: env, name: v.Name}
+}
+
+func (r *Ref) Get() interface{} {
+	v, _ := r.env.GetVar(r.name)
+	return v
+}
+
+// Set menulis ke scope tempat variabel itu berada, bukan membuat variabel
+// baru di scope saat ini.
+func (r *Ref) Set(val interface{}) {
+	assignExisting(r.env, r.name, val)
+}
+
+func (b *Builtin) isRef(i int) bool {
+	for _, r := range b.Refs {
+		if r == i {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	builtinsMu sync.RWMutex
+	builtins   = map[string]*Builtin{}
+)
+
+// RegisterBuiltin mendaftarkan fungsi Go supaya bisa dipanggil dari script.
+// Dipakai oleh stdlib di package ini maupun oleh aplikasi yang meng-embed
+// MonyetLang. Nama yang sama akan ditimpa.
+func RegisterBuiltin(b Builtin) {
+	if b.Name == "" || b.Fn == nil {
+		panic("RegisterBuiltin: Name dan Fn wajib diisi")
+	}
+	for _, t := range b.Params {
+		if t != "" && !isValidType(t, false) {
+			panic(fmt.Sprintf("RegisterBuiltin %s: tipe %q tidak dikenal", b.Name, t))
+		}
+	}
+	builtinsMu.Lock()
+	defer builtinsMu.Unlock()
+	builtins[b.Name] = &b
+}
+
+// LookupBuiltin mencari fungsi bawaan berdasarkan nama.
+func LookupBuiltin(name string) (*Builtin, bool) {
+	builtinsMu.RLock()
+	defer builtinsMu.RUnlock()
+	b, ok := builtins[name]
+	return b, ok
+}
+
+// BuiltinNames mengembalikan nama semua fungsi bawaan, terurut.
+func BuiltinNames() []string {
+	builtinsMu.RLock()
+	defer builtinsMu.RUnlock()
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (b *Builtin) acceptsArgs(n int) bool {
+	return n >= b.MinArgs && (b.MaxArgs < 0 || n <= b.MaxArgs)
+}
+
+func (b *Builtin) arityString() string {
+	switch {
+	case b.MaxArgs < 0:
+		return fmt.Sprintf("minimal %d", b.MinArgs)
+	case b.MinArgs == b.MaxArgs:
+		return fmt.Sprintf("%d", b.MinArgs)
+	}
+	return fmt.Sprintf("%d-%d", b.MinArgs, b.MaxArgs)
+}
+
+func (b *Builtin) call(env *Env, args []interface{}) interface{} {
+	if !b.acceptsArgs(len(args)) {
+		panic(fmt.Sprintf("%s() butuh %s argumen, dapat %d", b.Name, b.arityString(), len(args)))
+	}
+	for i, t := range b.Params {
+		if i < len(args) && t != "" && !b.isRef(i) {
+			checkType(t, args[i], fmt.Sprintf("argumen ke-%d %s()", i+1, b.Name))
+		}
+	}
+	return b.Fn(env, args)
+}
+
+// argString mengubah argumen apa pun menjadi string, seperti konkatenasi.
+func argString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return formatValue(v)
+}
+
+// formatValue seperti %v, tapi angka bulat ditulis utuh (1706670000, bukan
+// 1.70667e+09) supaya hasil echo, konkatenasi dan set_data bisa dibaca ulang.
+func formatValue(v interface{}) string {
+	if n, ok := v.(float64); ok && n == math.Trunc(n) && !math.IsInf(n, 0) {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// baseDir mengembalikan direktori script utama (__BASE_DIR__).
+func baseDir(env *Env) string {
+	dir, _ := env.GetVar("__BASE_DIR__")
+	s, _ := dir.(string)
+	return s
+}
+
+// toNumber mengubah argumen menjadi angka dengan aturan yang longgar seperti
+// PHP: string numerik diparse, true = 1, selain itu 0.
+func toNumber(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int:
+		return float64(n)
+	case bool:
+		if n {
+			return 1
+		}
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		if err == nil {
+			return f
+		}
+	}
+	return 0
+}
+
+// toInt sama seperti toNumber tapi dibulatkan ke bawah menjadi int.
+func toInt(v interface{}) int {
+	return int(toNumber(v))
+}
+
+// orderedKeys mengurutkan key map supaya iterasi deterministik: key angka
+// ("0", "1", ...) diurutkan secara numerik lebih dulu, baru key string.
+func orderedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ni, errI := strconv.ParseFloat(keys[i], 64)
+		nj, errJ := strconv.ParseFloat(keys[j], 64)
+		switch {
+		case errI == nil && errJ == nil:
+			return ni < nj
+		case errI == nil:
+			return true
+		case errJ == nil:
+			return false
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// listValues mengambil semua nilai dari array (slice) atau map secara urut.
+func listValues(v interface{}) []interface{} {
+	switch c := v.(type) {
+	case []interface{}:
+		return c
+	case map[string]interface{}:
+		out := make([]interface{}, 0, len(c))
+		for _, k := range orderedKeys(c) {
+			out = append(out, c[k])
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,530 @@
+package monyet
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Array di MonyetLang punya dua bentuk: []interface{} (hasil json_decode
+// atau fungsi array) dan map[string]interface{} (hasil literal [..]).
+// Map dengan key "0", "1", ... dianggap list biasa. Fungsi di sini menerima
+// keduanya dan mengembalikan []interface{} untuk list.
+
+func init() {
+	arr := []string{"array"}
+	RegisterBuiltin(Builtin{Name: "count", MinArgs: 1, MaxArgs: 1, Returns: "int", Fn: builtinCount})
+	RegisterBuiltin(Builtin{Name: "sizeof", MinArgs: 1, MaxArgs: 1, Returns: "int", Fn: builtinCount})
+	RegisterBuiltin(Builtin{Name: "array_keys", MinArgs: 1, MaxArgs: 1, Params: arr, Returns: "array", Fn: builtinArrayKeys})
+	RegisterBuiltin(Builtin{Name: "array_values", MinArgs: 1, MaxArgs: 1, Params: arr, Returns: "array", Fn: builtinArrayValues})
+	RegisterBuiltin(Builtin{Name: "in_array", MinArgs: 2, MaxArgs: 3, Params: []string{"", "array", "bool"}, Returns: "bool", Fn: builtinInArray})
+	RegisterBuiltin(Builtin{Name: "array_search", MinArgs: 2, MaxArgs: 3, Params: []string{"", "array", "bool"}, Fn: builtinArraySearch})
+	RegisterBuiltin(Builtin{Name: "array_key_exists", MinArgs: 2, MaxArgs: 2, Params: []string{"", "array"}, Returns: "bool", Fn: builtinArrayKeyExists})
+	RegisterBuiltin(Builtin{Name: "array_merge", MinArgs: 1, MaxArgs: -1, Returns: "array", Fn: builtinArrayMerge})
+	RegisterBuiltin(Builtin{Name: "array_slice", MinArgs: 2, MaxArgs: 3, Params: arr, Returns: "array", Fn: builtinArraySlice})
+	RegisterBuiltin(Builtin{Name: "array_push", MinArgs: 2, MaxArgs: -1, Refs: []int{0}, Returns: "int", Fn: builtinArrayPush})
+	RegisterBuiltin(Builtin{Name: "array_pop", MinArgs: 1, MaxArgs: 1, Refs: []int{0}, Fn: builtinArrayPop})
+	RegisterBuiltin(Builtin{Name: "array_shift", MinArgs: 1, MaxArgs: 1, Refs: []int{0}, Fn: builtinArrayShift})
+	RegisterBuiltin(Builtin{Name: "array_unshift", MinArgs: 2, MaxArgs: -1, Refs: []int{0}, Returns: "int", Fn: builtinArrayUnshift})
+	RegisterBuiltin(Builtin{Name: "array_map", MinArgs: 2, MaxArgs: 2, Params: []string{"callable", "array"}, Returns: "array", Fn: builtinArrayMap})
+	RegisterBuiltin(Builtin{Name: "array_filter", MinArgs: 1, MaxArgs: 2, Params: []string{"array", "callable"}, Returns: "array", Fn: builtinArrayFilter})
+	RegisterBuiltin(Builtin{Name: "array_reduce", MinArgs: 2, MaxArgs: 3, Params: []string{"array", "callable"}, Fn: builtinArrayReduce})
+	RegisterBuiltin(Builtin{Name: "usort", MinArgs: 2, MaxArgs: 2, Refs: []int{0}, Params: []string{"", "callable"}, Returns: "bool", Fn: builtinUsort})
+	RegisterBuiltin(Builtin{Name: "sort", MinArgs: 1, MaxArgs: 1, Refs: []int{0}, Returns: "bool", Fn: sortFunc(false)})
+	RegisterBuiltin(Builtin{Name: "rsort", MinArgs: 1, MaxArgs: 1, Refs: []int{0}, Returns: "bool", Fn: sortFunc(true)})
+	RegisterBuiltin(Builtin{Name: "array_column", MinArgs: 2, MaxArgs: 3, Params: arr, Returns: "array", Fn: builtinArrayColumn})
+	RegisterBuiltin(Builtin{Name: "array_reverse", MinArgs: 1, MaxArgs: 1, Params: arr, Returns: "array", Fn: builtinArrayReverse})
+	RegisterBuiltin(Builtin{Name: "array_unique", MinArgs: 1, MaxArgs: 1, Params: arr, Returns: "array", Fn: builtinArrayUnique})
+	RegisterBuiltin(Builtin{Name: "array_sum", MinArgs: 1, MaxArgs: 1, Params: arr, Fn: builtinArraySum})
+	RegisterBuiltin(Builtin{Name: "range", MinArgs: 2, MaxArgs: 3, Returns: "array", Fn: builtinRange})
+}
+
+// isList mengecek apakah nilai adalah list berurutan (bukan map asosiatif).
+func isList(v interface{}) bool {
+	switch c := v.(type) {
+	case []interface{}:
+		return true
+	case map[string]interface{}:
+		for i := 0; i < len(c); i++ {
+			if _, ok := c[strconv.Itoa(i)]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// arrayEntries mengembalikan pasangan key/value secara urut. Key list
+// berupa angka (float64), key map asosiatif berupa string.
+func arrayEntries(v interface{}) (keys []interface{}, vals []interface{}) {
+	switch c := v.(type) {
+	case []interface{}:
+		for i, val := range c {
+			keys = append(keys, float64(i))
+			vals = append(vals, val)
+		}
+	case map[string]interface{}:
+		list := isList(c)
+		for _, k := range orderedKeys(c) {
+			if list {
+				n, _ := strconv.Atoi(k)
+				keys = append(keys, float64(n))
+			} else {
+				keys = append(keys, k)
+			}
+			vals = append(vals, c[k])
+		}
+	}
+	return keys, vals
+}
+
+// looseEquals membandingkan seperti == di PHP: angka dan string numerik
+// dibandingkan sebagai angka.
+func looseEquals(a, b interface{}) bool {
+	if sa, ok := a.(string); ok {
+		if sb, ok := b.(string); ok {
+			return sa == sb
+		}
+	}
+	_, aNum := a.(float64)
+	_, bNum := b.(float64)
+	if aNum || bNum {
+		if isNumeric(a) && isNumeric(b) {
+			return toNumber(a) == toNumber(b)
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func isNumeric(v interface{}) bool {
+	switch n := v.(type) {
+	case float64, int:
+		return true
+	case string:
+		_, err := strconv.ParseFloat(n, 64)
+		return err == nil
+	}
+	return false
+}
+
+// compareValues dipakai sort(): angka dibanding secara numerik, selain itu
+// sebagai string.
+func compareValues(a, b interface{}) int {
+	if isNumeric(a) && isNumeric(b) {
+		x, y := toNumber(a), toNumber(b)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	x, y := argString(a), argString(b)
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func builtinCount(env *Env, args []interface{}) interface{} {
+	switch c := args[0].(type) {
+	case []interface{}:
+		return float64(len(c))
+	case map[string]interface{}:
+		return float64(len(c))
+	case nil:
+		return float64(0)
+	}
+	return float64(1)
+}
+
+func builtinArrayKeys(env *Env, args []interface{}) interface{} {
+	keys, _ := arrayEntries(args[0])
+	return append([]interface{}{}, keys...)
+}
+
+func builtinArrayValues(env *Env, args []interface{}) interface{} {
+	_, vals := arrayEntries(args[0])
+	return append([]interface{}{}, vals...)
+}
+
+func searchArray(args []interface{}) (key interface{}, found bool) {
+	strict := len(args) > 2 && isTruthy(args[2])
+	keys, vals := arrayEntries(args[1])
+	for i, v := range vals {
+		if strict && typeOf(v) == typeOf(args[0]) && reflect.DeepEqual(v, args[0]) {
+			return keys[i], true
+		}
+		if !strict && looseEquals(v, args[0]) {
+			return keys[i], true
+		}
+	}
+	return nil, false
+}
+
+func builtinInArray(env *Env, args []interface{}) interface{} {
+	_, found := searchArray(args)
+	return found
+}
+
+func builtinArraySearch(env *Env, args []interface{}) interface{} {
+	if key, found := searchArray(args); found {
+		return key
+	}
+	return false
+}
+
+func builtinArrayKeyExists(env *Env, args []interface{}) interface{} {
+	switch c := args[1].(type) {
+	case []interface{}:
+		i := toInt(args[0])
+		return isNumeric(args[0]) && i >= 0 && i < len(c)
+	case map[string]interface{}:
+		_, ok := c[argString(args[0])]
+		return ok
+	}
+	return false
+}
+
+// builtinArrayMerge: list disambung, key string ditimpa oleh array terakhir.
+func builtinArrayMerge(env *Env, args []interface{}) interface{} {
+	allLists := true
+	for _, a := range args {
+		if !isList(a) {
+			allLists = false
+		}
+	}
+	if allLists {
+		out := []interface{}{}
+		for _, a := range args {
+			out = append(out, listValues(a)...)
+		}
+		return out
+	}
+
+	out := map[string]interface{}{}
+	next := 0
+	for _, a := range args {
+		keys, vals := arrayEntries(a)
+		for i, k := range keys {
+			if _, isNum := k.(float64); isNum {
+				out[strconv.Itoa(next)] = vals[i]
+				next++
+			} else {
+				out[k.(string)] = vals[i]
+			}
+		}
+	}
+	return out
+}
+
+func builtinArraySlice(env *Env, args []interface{}) interface{} {
+	keys, vals := arrayEntries(args[0])
+	var length *int
+	if len(args) > 2 && args[2] != nil {
+		l := toInt(args[2])
+		length = &l
+	}
+	from, to := runeRange(len(vals), toInt(args[1]), length)
+	if isList(args[0]) {
+		return append([]interface{}{}, vals[from:to]...)
+	}
+	out := map[string]interface{}{}
+	for i := from; i < to; i++ {
+		out[argString(keys[i])] = vals[i]
+	}
+	return out
+}
+
+// appendValues menambah nilai di akhir array, mengembalikan array baru.
+func appendValues(target interface{}, items []interface{}) (interface{}, int) {
+	if target == nil || isList(target) {
+		list := append(append([]interface{}{}, listValues(target)...), items...)
+		return list, len(list)
+	}
+	m := target.(map[string]interface{})
+	next := 0
+	for k := range m {
+		if n, err := strconv.Atoi(k); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+	for _, item := range items {
+		m[strconv.Itoa(next)] = item
+		next++
+	}
+	return m, len(m)
+}
+
+func refArray(ref interface{}, fnName string) (*Ref, interface{}) {
+	r := ref.(*Ref)
+	val := r.Get()
+	switch val.(type) {
+	case []interface{}, map[string]interface{}, nil:
+		return r, val
+	}
+	panic(fmt.Sprintf("%s(): $%s bukan array", fnName, r.name))
+}
+
+func builtinArrayPush(env *Env, args []interface{}) interface{} {
+	r, val := refArray(args[0], "array_push")
+	newVal, n := appendValues(val, args[1:])
+	r.Set(newVal)
+	return float64(n)
+}
+
+func builtinArrayPop(env *Env, args []interface{}) interface{} {
+	r, val := refArray(args[0], "array_pop")
+	keys, vals := arrayEntries(val)
+	if len(vals) == 0 {
+		return nil
+	}
+	last := vals[len(vals)-1]
+	if isList(val) {
+		r.Set(append([]interface{}{}, vals[:len(vals)-1]...))
+	} else {
+		delete(val.(map[string]interface{}), argString(keys[len(keys)-1]))
+	}
+	return last
+}
+
+func builtinArrayShift(env *Env, args []interface{}) interface{} {
+	r, val := refArray(args[0], "array_shift")
+	keys, vals := arrayEntries(val)
+	if len(vals) == 0 {
+		return nil
+	}
+	if isList(val) {
+		r.Set(append([]interface{}{}, vals[1:]...))
+	} else {
+		delete(val.(map[string]interface{}), argString(keys[0]))
+	}
+	return vals[0]
+}
+
+func builtinArrayUnshift(env *Env, args []interface{}) interface{} {
+	r, val := refArray(args[0], "array_unshift")
+	if !isList(val) && val != nil {
+		panic("array_unshift(): hanya untuk list")
+	}
+	list := append(append([]interface{}{}, args[1:]...), listValues(val)...)
+	r.Set(list)
+	return float64(len(list))
+}
+
+// builtinArrayMap memanggil callback untuk setiap elemen. Key map
+// asosiatif dipertahankan.
+func builtinArrayMap(env *Env, args []interface{}) interface{} {
+	keys, vals := arrayEntries(args[1])
+	if isList(args[1]) {
+		out := make([]interface{}, len(vals))
+		for i, v := range vals {
+			out[i] = callValue(args[0], []interface{}{v}, env)
+		}
+		return out
+	}
+	out := map[string]interface{}{}
+	for i, v := range vals {
+		out[keys[i].(string)] = callValue(args[0], []interface{}{v}, env)
+	}
+	return out
+}
+
+// builtinArrayFilter menyimpan elemen yang callback-nya truthy. Tanpa
+// callback, elemen yang falsy dibuang. Hasil untuk list diberi index ulang.
+func builtinArrayFilter(env *Env, args []interface{}) interface{} {
+	keys, vals := arrayEntries(args[0])
+	keep := func(v interface{}) bool {
+		if len(args) > 1 && args[1] != nil {
+			return isTruthy(callValue(args[1], []interface{}{v}, env))
+		}
+		return isTruthy(v)
+	}
+	if isList(args[0]) {
+		out := []interface{}{}
+		for _, v := range vals {
+			if keep(v) {
+				out = append(out, v)
+			}
+		}
+		return out
+	}
+	out := map[string]interface{}{}
+	for i, v := range vals {
+		if keep(v) {
+			out[keys[i].(string)] = v
+		}
+	}
+	return out
+}
+
+func builtinArrayReduce(env *Env, args []interface{}) interface{} {
+	var carry interface{}
+	if len(args) > 2 {
+		carry = args[2]
+	}
+	for _, v := range listValues(args[0]) {
+		carry = callValue(args[1], []interface{}{carry, v}, env)
+	}
+	return carry
+}
+
+// builtinUsort mengurutkan list memakai comparator yang mengembalikan
+// angka negatif, nol, atau positif.
+func builtinUsort(env *Env, args []interface{}) interface{} {
+	r, val := refArray(args[0], "usort")
+	list := append([]interface{}{}, listValues(val)...)
+	sort.SliceStable(list, func(i, j int) bool {
+		return toNumber(callValue(args[1], []interface{}{list[i], list[j]}, env)) < 0
+	})
+	r.Set(list)
+	return true
+}
+
+func sortFunc(reverse bool) BuiltinFunc {
+	return func(env *Env, args []interface{}) interface{} {
+		r, val := refArray(args[0], "sort")
+		list := append([]interface{}{}, listValues(val)...)
+		sort.SliceStable(list, func(i, j int) bool {
+			if reverse {
+				return compareValues(list[i], list[j]) > 0
+			}
+			return compareValues(list[i], list[j]) < 0
+		})
+		r.Set(list)
+		return true
+	}
+}
+
+// builtinArrayColumn mengambil satu kolom dari list record, opsional
+// di-index dengan kolom lain: array_column($rows, "nama", "id").
+func builtinArrayColumn(env *Env, args []interface{}) interface{} {
+	column := args[1]
+	var list []interface{}
+	byKey := map[string]interface{}{}
+	indexed := len(args) > 2 && args[2] != nil
+
+	for _, row := range listValues(args[0]) {
+		var val interface{}
+		if column == nil {
+			val = row
+		} else {
+			val = indexValue(row, column)
+			if val == nil {
+				continue
+			}
+		}
+		if indexed {
+			byKey[argString(indexValue(row, args[2]))] = val
+		} else {
+			list = append(list, val)
+		}
+	}
+	if indexed {
+		return byKey
+	}
+	if list == nil {
+		return []interface{}{}
+	}
+	return list
+}
+
+func builtinArrayReverse(env *Env, args []interface{}) interface{} {
+	vals := listValues(args[0])
+	out := make([]interface{}, len(vals))
+	for i, v := range vals {
+		out[len(vals)-1-i] = v
+	}
+	return out
+}
+
+func builtinArrayUnique(env *Env, args []interface{}) interface{} {
+	out := []interface{}{}
+	for _, v := range listValues(args[0]) {
+		dup := false
+		for _, seen := range out {
+			if looseEquals(seen, v) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func builtinArraySum(env *Env, args []interface{}) interface{} {
+	sum := 0.0
+	for _, v := range listValues(args[0]) {
+		sum += toNumber(v)
+	}
+	return sum
+}
+
+// builtinRange membuat list angka dari start sampai end (inklusif).
+func builtinRange(env *Env, args []interface{}) interface{} {
+	step := 1.0
+	if len(args) > 2 {
+		step = toNumber(args[2])
+	}
+	if step < 0 {
+		step = -step
+	}
+	if step == 0 {
+		panic("range(): step tidak boleh 0")
+	}
+
+	// range("a", "e") seperti PHP: huruf per huruf
+	from, fromChar := rangeChar(args[0])
+	to, toChar := rangeChar(args[1])
+	if fromChar && toChar {
+		n, dir := max(1, int(step)), 1
+		if from > to {
+			dir = -1
+		}
+		out := []interface{}{}
+		for c := int(from); c*dir <= int(to)*dir; c += n * dir {
+			out = append(out, string(rune(c)))
+		}
+		return out
+	}
+	start, end := rangeNumber(args[0]), rangeNumber(args[1])
+	out := []interface{}{}
+	if start <= end {
+		for x := start; x <= end; x += step {
+			out = append(out, x)
+		}
+	} else {
+		for x := start; x >= end; x -= step {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+// rangeChar mengenali batas berupa satu huruf yang bukan angka.
+func rangeChar(v interface{}) (byte, bool) {
+	s, ok := v.(string)
+	if !ok || len(s) != 1 || (s[0] >= '0' && s[0] <= '9') {
+		return 0, false
+	}
+	return s[0], true
+}
+
+func rangeNumber(v interface{}) float64 {
+	if s, ok := v.(string); ok {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err != nil {
+			panic(fmt.Sprintf("range(): batas %q bukan angka atau satu huruf", s))
+		}
+	}
+	return toNumber(v)
+}
@@ -0,0 +1,31 @@
+package monyet
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRange(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []interface{}
+	}{
+		{`range(1, 3)`, []interface{}{1.0, 2.0, 3.0}},
+		{`range(10, 0, 5)`, []interface{}{10.0, 5.0, 0.0}},
+		{`range("1", "3")`, []interface{}{1.0, 2.0, 3.0}},
+		{`range("a", "e")`, []interface{}{"a", "b", "c", "d", "e"}},
+		{`range("e", "a", 2)`, []interface{}{"e", "c", "a"}},
+	}
+	for _, tt := range tests {
+		if got := evalExpr(t, tt.src); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %#v, want %#v", tt.src, got, tt.want)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error(`range("ab", "e") harus panic`)
+		}
+	}()
+	evalExpr(t, `range("ab", "e")`)
+}
@@ -0,0 +1,74 @@
+package monyet
+
+import (
+	"strconv"
+	"strings"
+)
+
+func init() {
+	RegisterBuiltin(Builtin{Name: "define", MinArgs: 2, MaxArgs: 2, Params: []string{"string", ""}, Returns: "bool", Fn: builtinDefine})
+	RegisterBuiltin(Builtin{Name: "defined", MinArgs: 1, MaxArgs: 1, Params: []string{"string"}, Returns: "bool", Fn: builtinDefined})
+	RegisterBuiltin(Builtin{Name: "config", MinArgs: 0, MaxArgs: 2, Params: []string{"string"}, Fn: builtinConfig})
+	RegisterBuiltin(Builtin{Name: "file_lines", MinArgs: 1, MaxArgs: 1, Params: []string{"string"}, Returns: "generator", Fn: builtinFileLines})
+}
+
+func builtinDefine(env *Env, args []interface{}) interface{} {
+	name := args[0].(string)
+	if name == "" {
+		panic("define() membutuhkan nama konstanta")
+	}
+	env.DefineConst(name, args[1])
+	return true
+}
+
+func builtinDefined(env *Env, args []interface{}) interface{} {
+	return env.IsConst(args[0].(string))
+}
+
+// builtinConfig: config("port", 8080). Nilai dari .env/env/CLI selalu
+// string, jadi kalau default-nya angka atau bool nilainya ikut dikonversi.
+// config() tanpa argumen mengembalikan semua key.
+func builtinConfig(env *Env, args []interface{}) interface{} {
+	cfg := scriptConfig(env)
+	if len(args) == 0 {
+		out := map[string]interface{}{}
+		for _, k := range cfg.Keys() {
+			out[k], _ = cfg.Get(k)
+		}
+		return out
+	}
+
+	var def interface{}
+	if len(args) > 1 {
+		def = args[1]
+	}
+	val, ok := cfg.Get(args[0].(string))
+	if !ok {
+		return def
+	}
+	s, isString := val.(string)
+	if !isString {
+		return val
+	}
+	switch def.(type) {
+	case float64:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+			return f
+		}
+		return def
+	case bool:
+		switch strings.ToLower(strings.TrimSpace(s)) {
+		case "1", "true", "yes", "on":
+			return true
+		case "0", "false", "no", "off", "":
+			return false
+		}
+		return def
+	}
+	return s
+}
+
+func builtinFileLines(env *Env, args []interface{}) interface{} {
+	root, name := fsPath(env, args[0].(string))
+	return fileLines(root, name)
+}
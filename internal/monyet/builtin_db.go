@@ -0,0 +1,155 @@
+package monyet
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sync"
+)
+
+// storages menyimpan database yang sudah dibuka per path. Request yang
+// berjalan bersamaan memakai *MonyetDB yang sama (MonyetDB punya lock
+// sendiri), jadi file database hanya dibuka sekali.
+var storages = struct {
+	mu sync.Mutex
+	m  map[string]*MonyetDB
+}{m: map[string]*MonyetDB{}}
+
+func init() {
+	RegisterBuiltin(Builtin{Name: "set_data", MinArgs: 2, MaxArgs: 2, Returns: "bool", Fn: builtinSetData})
+	RegisterBuiltin(Builtin{Name: "get_data", MinArgs: 1, MaxArgs: 1, Returns: "string", Fn: builtinGetData})
+	RegisterBuiltin(Builtin{Name: "delete_data", MinArgs: 1, MaxArgs: 1, Returns: "bool", Fn: builtinDeleteData})
+	RegisterBuiltin(Builtin{Name: "drop_db", MinArgs: 0, MaxArgs: 0, Returns: "bool", Fn: builtinDropDB})
+	RegisterBuiltin(Builtin{Name: "db_scan", MinArgs: 0, MaxArgs: 1, Returns: "generator", Fn: builtinDBScan})
+}
+
+// getStorage membuka (atau memakai ulang) database sesuai DB_NAME saat ini.
+// Config db_path (misal dari .env) menang atas DB_NAME di script.
+func getStorage(env *Env) *MonyetDB {
+	base := baseDir(env)
+	dbPath := filepath.Join(base, "monyet.db")
+
+	if path := configString(env, "db_path"); path != "" {
+		dbPath = path
+		if !filepath.IsAbs(path) {
+			dbPath = filepath.Join(base, path)
+		}
+	} else if customName, ok := env.GetVar("DB_NAME"); ok {
+		dbPath = filepath.Join(base, fmt.Sprintf("%v", customName))
+	}
+
+	storages.mu.Lock()
+	defer storages.mu.Unlock()
+	// Jika storage untuk path ini sudah ada, pakai yang lama saja
+	if db, ok := storages.m[dbPath]; ok {
+		return db
+	}
+	db := NewMonyetDB(dbPath)
+	storages.m[dbPath] = db
+	return db
+}
+
+// closeStorages menutup semua database yang terbuka. Dipanggil saat
+// server berhenti atau script selesai; pemakaian berikutnya membuka ulang.
+func closeStorages() {
+	storages.mu.Lock()
+	defer storages.mu.Unlock()
+	for path, db := range storages.m {
+		if err := db.Close(); err != nil {
+			fmt.Printf("Gagal menutup database %s: %v\n", path, err)
+		}
+		delete(storages.m, path)
+	}
+}
+
+// dataKey mengubah key set_data/get_data menjadi string. Dulu key angka
+// ditulis dengan %v, jadi 1000000 tersimpan sebagai "1e+06". legacy berisi
+// bentuk lama itu (kalau berbeda) supaya data lama tetap terbaca.
+func dataKey(k interface{}) (key, legacy string) {
+	key = formatValue(k)
+	if old := fmt.Sprintf("%v", k); old != key {
+		legacy = old
+	}
+	return key, legacy
+}
+
+func builtinSetData(env *Env, args []interface{}) interface{} {
+	k, legacy := dataKey(args[0])
+	val := args[1]
+
+	// Jika yang dikirim adalah Map atau Array, otomatis JSON-kan
+	switch val.(type) {
+	case map[string]interface{}, []interface{}:
+		jsonBytes, _ := json.Marshal(val)
+		val = string(jsonBytes)
+	case float64:
+		val = formatValue(val)
+	}
+
+	db := getStorage(env)
+	db.Set(k, val)
+	// key lama dipindah: ditulis ulang dengan format baru, yang lama dihapus
+	if legacy != "" && db.Has(legacy) {
+		db.Delete(legacy)
+	}
+	return true
+}
+
+func builtinGetData(env *Env, args []interface{}) interface{} {
+	if args[0] == nil {
+		return ""
+	}
+	key, legacy := dataKey(args[0])
+	db := getStorage(env)
+	if legacy != "" && !db.Has(key) {
+		return db.Get(legacy)
+	}
+	return db.Get(key)
+}
+
+func builtinDeleteData(env *Env, args []interface{}) interface{} {
+	if args[0] == nil {
+		return false
+	}
+	k, legacy := dataKey(args[0])
+	if k == "" {
+		return false
+	}
+	db := getStorage(env)
+	db.Delete(k)
+	if legacy != "" && db.Has(legacy) {
+		db.Delete(legacy)
+	}
+	return true
+}
+
+func builtinDropDB(env *Env, args []interface{}) interface{} {
+	// Memanggil fungsi Drop() untuk menghapus file fisik database
+	db := getStorage(env)
+	err := db.Drop()
+	// File sudah ditutup, pemakaian berikutnya membuka database baru
+	storages.mu.Lock()
+	if storages.m[db.path] == db {
+		delete(storages.m, db.path)
+	}
+	storages.mu.Unlock()
+	if err != nil {
+		fmt.Printf("Gagal menghapus database: %v\n", err)
+		return false
+	}
+	return true
+}
+
+// builtinDBScan mengembalikan semua key yang masih hidup, dibaca lazy
+// langsung dari file log.
+func builtinDBScan(env *Env, args []interface{}) interface{} {
+	prefix := ""
+	if len(args) > 0 {
+		prefix = argString(args[0])
+	}
+	cur := getStorage(env).Cursor(prefix)
+	return NewGenerator(func() (interface{}, interface{}, bool) {
+		k, val, ok := cur.Next()
+		return k, val, ok
+	}, cur.Close)
+}
@@ -0,0 +1,47 @@
+package monyet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDataNumericKeys(t *testing.T) {
+	dir := t.TempDir()
+	// baris dari versi lama: key 1000000 dan nilai 2500000 ditulis dengan %v
+	old := "1e+06:lama\nharga:2.5e+06\n"
+	if err := os.WriteFile(filepath.Join(dir, "monyet.db"), []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env := NewEnv()
+	env.SetVar("__BASE_DIR__", dir)
+	t.Cleanup(closeStorages)
+
+	if got := builtinGetData(env, []interface{}{1000000.0}); got != "lama" {
+		t.Errorf("get_data(1000000) dari data lama = %q", got)
+	}
+	if got := builtinGetData(env, []interface{}{"harga"}); got != "2.5e+06" {
+		t.Errorf("nilai lama berubah: %q", got)
+	}
+
+	builtinSetData(env, []interface{}{1000000.0, 1706670000.0})
+	db := getStorage(env)
+	if got := db.Get("1000000"); got != "1706670000" {
+		t.Errorf("key baru = %q", got)
+	}
+	if db.Get("1e+06") != "" {
+		t.Error("key lama seharusnya dihapus setelah set_data")
+	}
+	if got := builtinGetData(env, []interface{}{1000000.0}); got != "1706670000" {
+		t.Errorf("get_data(1000000) = %q", got)
+	}
+
+	builtinSetData(env, []interface{}{2000000.0, "x"})
+	builtinDeleteData(env, []interface{}{2000000.0})
+	if got := builtinGetData(env, []interface{}{2000000.0}); got != "" {
+		t.Errorf("setelah delete_data = %q", got)
+	}
+	if builtinDeleteData(env, []interface{}{nil}) != false {
+		t.Error("delete_data(null) harus false")
+	}
+}
@@ -0,0 +1,417 @@
+package monyet
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	fileAppend             = 8 // nilai FILE_APPEND di PHP
+	scandirSortDescending  = 1
+	defaultFilePermissions = 0644
+)
+
+// Semua fungsi file bekerja di dalam satu root directory: __FS_ROOT__ kalau
+// di-set, kalau tidak direktori script utama (__BASE_DIR__). Path relatif
+// dihitung dari root, path absolut hanya boleh kalau masih di dalam root.
+// os.Root juga menolak symlink yang mengarah keluar root.
+var fsRoots sync.Map // dir -> *os.Root
+
+// FileHandle adalah resource hasil fopen(), juga dipakai untuk STDIN,
+// STDOUT dan STDERR.
+type FileHandle struct {
+	name   string
+	f      *os.File
+	r      *bufio.Reader
+	std    bool // stdio milik proses, tidak benar-benar ditutup oleh fclose
+	closed bool
+}
+
+func (h *FileHandle) String() string {
+	return "Resource(" + h.name + ")"
+}
+
+func init() {
+	predefinedConsts["FILE_APPEND"] = float64(fileAppend)
+	predefinedConsts["LOCK_EX"] = float64(2) // diterima supaya kompatibel, tidak berefek
+	predefinedConsts["SCANDIR_SORT_ASCENDING"] = float64(0)
+	predefinedConsts["SCANDIR_SORT_DESCENDING"] = float64(scandirSortDescending)
+
+	str := []string{"string"}
+	RegisterBuiltin(Builtin{Name: "file_get_contents", MinArgs: 1, MaxArgs: 1, Params: str, Fn: builtinFileGetContents})
+	RegisterBuiltin(Builtin{Name: "file_put_contents", MinArgs: 2, MaxArgs: 3, Params: str, Fn: builtinFilePutContents})
+	RegisterBuiltin(Builtin{Name: "file_exists", MinArgs: 1, MaxArgs: 1, Params: str, Returns: "bool", Fn: statCheck(func(fs.FileInfo) bool { return true })})
+	RegisterBuiltin(Builtin{Name: "is_file", MinArgs: 1, MaxArgs: 1, Params: str, Returns: "bool", Fn: statCheck(func(fi fs.FileInfo) bool { return fi.Mode().IsRegular() })})
+	RegisterBuiltin(Builtin{Name: "is_dir", MinArgs: 1, MaxArgs: 1, Params: str, Returns: "bool", Fn: statCheck(func(fi fs.FileInfo) bool { return fi.IsDir() })})
+	RegisterBuiltin(Builtin{Name: "filesize", MinArgs: 1, MaxArgs: 1, Params: str, Fn: builtinFilesize})
+	RegisterBuiltin(Builtin{Name: "filemtime", MinArgs: 1, MaxArgs: 1, Params: str, Fn: builtinFilemtime})
+	RegisterBuiltin(Builtin{Name: "unlink", MinArgs: 1, MaxArgs: 1, Params: str, Returns: "bool", Fn: builtinUnlink})
+	RegisterBuiltin(Builtin{Name: "mkdir", MinArgs: 1, MaxArgs: 3, Params: str, Returns: "bool", Fn: builtinMkdir})
+	RegisterBuiltin(Builtin{Name: "rmdir", MinArgs: 1, MaxArgs: 1, Params: str, Returns: "bool", Fn: builtinRmdir})
+	RegisterBuiltin(Builtin{Name: "rename", MinArgs: 2, MaxArgs: 2, Params: []string{"string", "string"}, Returns: "bool", Fn: builtinRename})
+	RegisterBuiltin(Builtin{Name: "copy", MinArgs: 2, MaxArgs: 2, Params: []string{"string", "string"}, Returns: "bool", Fn: builtinCopy})
+	RegisterBuiltin(Builtin{Name: "scandir", MinArgs: 1, MaxArgs: 2, Params: str, Fn: builtinScandir})
+	RegisterBuiltin(Builtin{Name: "glob", MinArgs: 1, MaxArgs: 1, Params: str, Returns: "array", Fn: builtinGlob})
+	RegisterBuiltin(Builtin{Name: "fopen", MinArgs: 2, MaxArgs: 2, Params: []string{"string", "string"}, Fn: builtinFopen})
+	RegisterBuiltin(Builtin{Name: "fgets", MinArgs: 1, MaxArgs: 1, Fn: builtinFgets})
+	RegisterBuiltin(Builtin{Name: "fread", MinArgs: 2, MaxArgs: 2, Fn: builtinFread})
+	RegisterBuiltin(Builtin{Name: "fwrite", MinArgs: 2, MaxArgs: 2, Fn: builtinFwrite})
+	RegisterBuiltin(Builtin{Name: "fputs", MinArgs: 2, MaxArgs: 2, Fn: builtinFwrite})
+	RegisterBuiltin(Builtin{Name: "feof", MinArgs: 1, MaxArgs: 1, Returns: "bool", Fn: builtinFeof})
+	RegisterBuiltin(Builtin{Name: "fclose", MinArgs: 1, MaxArgs: 1, Returns: "bool", Fn: builtinFclose})
+}
+
+// fsRoot membuka (sekali, lalu di-cache) root directory milik script.
+func fsRoot(env *Env) (*os.Root, string) {
+	dir := baseDir(env)
+	if v, ok := env.GetVar("__FS_ROOT__"); ok {
+		if s, ok := v.(string); ok && s != "" {
+			dir = s
+		}
+	}
+	if dir == "" {
+		dir = "."
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	r, err := openRoot(dir)
+	if err != nil {
+		panic(fmt.Sprintf("filesystem: root %s tidak bisa dibuka: %v", dir, err))
+	}
+	return r, dir
+}
+
+// openRoot membuka os.Root untuk dir (path absolut) sekali saja. Dipakai
+// juga oleh render() supaya template tidak bisa keluar dari foldernya.
+func openRoot(dir string) (*os.Root, error) {
+	if r, ok := fsRoots.Load(dir); ok {
+		return r.(*os.Root), nil
+	}
+	r, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	actual, loaded := fsRoots.LoadOrStore(dir, r)
+	if loaded {
+		r.Close()
+	}
+	return actual.(*os.Root), nil
+}
+
+// fsPath mengubah path dari script menjadi nama relatif terhadap root.
+// Path yang keluar dari root langsung ditolak.
+func fsPath(env *Env, path string) (*os.Root, string) {
+	root, dir := fsRoot(env)
+	name := path
+	if filepath.IsAbs(path) {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			panic(fmt.Sprintf("akses ditolak: %s di luar root filesystem", path))
+		}
+		name = rel
+	}
+	name = filepath.Clean(name)
+	if !filepath.IsLocal(name) && name != "." {
+		panic(fmt.Sprintf("akses ditolak: %s di luar root filesystem", path))
+	}
+	return root, name
+}
+
+// fsWarn mencetak warning ke stderr dan mengembalikan false, seperti fungsi
+// file di PHP yang gagal.
+func fsWarn(fnName string, err error) interface{} {
+	fmt.Fprintf(os.Stderr, "Warning: %s(): %v\n", fnName, err)
+	return false
+}
+
+func builtinFileGetContents(env *Env, args []interface{}) interface{} {
+	root, name := fsPath(env, args[0].(string))
+	b, err := root.ReadFile(name)
+	if err != nil {
+		return fsWarn("file_get_contents", err)
+	}
+	return string(b)
+}
+
+// builtinFilePutContents: file_put_contents("log.txt", $line, FILE_APPEND).
+// Data berupa array ditulis sebagai gabungan nilainya.
+func builtinFilePutContents(env *Env, args []interface{}) interface{} {
+	root, name := fsPath(env, args[0].(string))
+	var data string
+	if vals := listValues(args[1]); vals != nil {
+		var sb strings.Builder
+		for _, v := range vals {
+			sb.WriteString(argString(v))
+		}
+		data = sb.String()
+	} else {
+		data = argString(args[1])
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if len(args) > 2 && toInt(args[2])&fileAppend != 0 {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	f, err := root.OpenFile(name, flags, defaultFilePermissions)
+	if err != nil {
+		return fsWarn("file_put_contents", err)
+	}
+	defer f.Close()
+	n, err := f.WriteString(data)
+	if err != nil {
+		return fsWarn("file_put_contents", err)
+	}
+	return float64(n)
+}
+
+func statCheck(pred func(fs.FileInfo) bool) BuiltinFunc {
+	return func(env *Env, args []interface{}) interface{} {
+		root, name := fsPath(env, args[0].(string))
+		fi, err := root.Stat(name)
+		return err == nil && pred(fi)
+	}
+}
+
+func builtinFilesize(env *Env, args []interface{}) interface{} {
+	root, name := fsPath(env, args[0].(string))
+	fi, err := root.Stat(name)
+	if err != nil {
+		return fsWarn("filesize", err)
+	}
+	return float64(fi.Size())
+}
+
+func builtinFilemtime(env *Env, args []interface{}) interface{} {
+	root, name := fsPath(env, args[0].(string))
+	fi, err := root.Stat(name)
+	if err != nil {
+		return fsWarn("filemtime", err)
+	}
+	return float64(fi.ModTime().Unix())
+}
+
+func builtinUnlink(env *Env, args []interface{}) interface{} {
+	root, name := fsPath(env, args[0].(string))
+	if fi, err := root.Stat(name); err == nil && fi.IsDir() {
+		return fsWarn("unlink", fmt.Errorf("%s adalah direktori, pakai rmdir()", name))
+	}
+	if err := root.Remove(name); err != nil {
+		return fsWarn("unlink", err)
+	}
+	return true
+}
+
+// builtinMkdir: mkdir("uploads/2024", 0755, true). Argumen ketiga membuat
+// direktori induk sekalian.
+func builtinMkdir(env *Env, args []interface{}) interface{} {
+	root, name := fsPath(env, args[0].(string))
+	perm := os.FileMode(0777)
+	if len(args) > 1 && args[1] != nil {
+		perm = fileMode(toInt(args[1]))
+	}
+	var err error
+	if len(args) > 2 && isTruthy(args[2]) {
+		err = root.MkdirAll(name, perm)
+	} else {
+		err = root.Mkdir(name, perm)
+	}
+	if err != nil {
+		return fsWarn("mkdir", err)
+	}
+	return true
+}
+
+// fileMode membaca mode seperti 0755. Lexer tidak mengenal literal oktal,
+// jadi 0755 sampai di sini sebagai 755 desimal dan digit-digitnya dibaca
+// ulang sebagai oktal.
+func fileMode(n int) os.FileMode {
+	if m, err := strconv.ParseUint(strconv.Itoa(n), 8, 32); err == nil {
+		return os.FileMode(m) & fs.ModePerm
+	}
+	return os.FileMode(n) & fs.ModePerm
+}
+
+func builtinRmdir(env *Env, args []interface{}) interface{} {
+	root, name := fsPath(env, args[0].(string))
+	if fi, err := root.Stat(name); err == nil && !fi.IsDir() {
+		return fsWarn("rmdir", fmt.Errorf("%s bukan direktori", name))
+	}
+	if err := root.Remove(name); err != nil {
+		return fsWarn("rmdir", err)
+	}
+	return true
+}
+
+func builtinRename(env *Env, args []interface{}) interface{} {
+	root, from := fsPath(env, args[0].(string))
+	_, to := fsPath(env, args[1].(string))
+	if err := root.Rename(from, to); err != nil {
+		return fsWarn("rename", err)
+	}
+	return true
+}
+
+func builtinCopy(env *Env, args []interface{}) interface{} {
+	root, from := fsPath(env, args[0].(string))
+	_, to := fsPath(env, args[1].(string))
+	src, err := root.Open(from)
+	if err != nil {
+		return fsWarn("copy", err)
+	}
+	defer src.Close()
+	dst, err := root.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, defaultFilePermissions)
+	if err != nil {
+		return fsWarn("copy", err)
+	}
+	defer dst.Close()
+	if _, err := io.Copy(dst, src); err != nil {
+		return fsWarn("copy", err)
+	}
+	return true
+}
+
+// builtinScandir mengembalikan isi direktori termasuk "." dan "..", terurut
+// naik atau turun (SCANDIR_SORT_DESCENDING).
+func builtinScandir(env *Env, args []interface{}) interface{} {
+	root, name := fsPath(env, args[0].(string))
+	d, err := root.Open(name)
+	if err != nil {
+		return fsWarn("scandir", err)
+	}
+	defer d.Close()
+	entries, err := d.ReadDir(-1)
+	if err != nil {
+		return fsWarn("scandir", err)
+	}
+
+	names := []string{".", ".."}
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	if len(args) > 1 && toInt(args[1]) == scandirSortDescending {
+		sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	}
+	out := make([]interface{}, len(names))
+	for i, n := range names {
+		out[i] = n
+	}
+	return out
+}
+
+// builtinGlob: glob("data/*.json"). Path hasil selalu relatif terhadap root.
+func builtinGlob(env *Env, args []interface{}) interface{} {
+	root, pattern := fsPath(env, args[0].(string))
+	matches, err := fs.Glob(root.FS(), filepath.ToSlash(pattern))
+	if err != nil {
+		panic(fmt.Sprintf("glob(): pola %q tidak valid", args[0]))
+	}
+	out := make([]interface{}, len(matches))
+	for i, m := range matches {
+		out[i] = filepath.FromSlash(m)
+	}
+	return out
+}
+
+var fopenModes = map[string]int{
+	"r":  os.O_RDONLY,
+	"r+": os.O_RDWR,
+	"w":  os.O_WRONLY | os.O_CREATE | os.O_TRUNC,
+	"w+": os.O_RDWR | os.O_CREATE | os.O_TRUNC,
+	"a":  os.O_WRONLY | os.O_CREATE | os.O_APPEND,
+	"a+": os.O_RDWR | os.O_CREATE | os.O_APPEND,
+	"x":  os.O_WRONLY | os.O_CREATE | os.O_EXCL,
+	"x+": os.O_RDWR | os.O_CREATE | os.O_EXCL,
+	"c":  os.O_WRONLY | os.O_CREATE,
+	"c+": os.O_RDWR | os.O_CREATE,
+}
+
+func builtinFopen(env *Env, args []interface{}) interface{} {
+	mode := strings.NewReplacer("b", "", "t", "").Replace(args[1].(string))
+	flags, ok := fopenModes[mode]
+	if !ok {
+		panic(fmt.Sprintf("fopen(): mode %q tidak valid", args[1]))
+	}
+	root, name := fsPath(env, args[0].(string))
+	f, err := root.OpenFile(name, flags, defaultFilePermissions)
+	if err != nil {
+		return fsWarn("fopen", err)
+	}
+	return &FileHandle{name: args[0].(string), f: f, r: bufio.NewReader(f)}
+}
+
+func argHandle(v interface{}, fnName string) *FileHandle {
+	h, ok := v.(*FileHandle)
+	if !ok {
+		panic(fmt.Sprintf("%s() butuh resource dari fopen(), dapat %s", fnName, typeOf(v)))
+	}
+	if h.closed {
+		panic(fmt.Sprintf("%s(): resource %s sudah ditutup", fnName, h.name))
+	}
+	return h
+}
+
+// builtinFgets membaca satu baris termasuk "\n"-nya, atau false kalau
+// sudah di akhir file.
+func builtinFgets(env *Env, args []interface{}) interface{} {
+	h := argHandle(args[0], "fgets")
+	line, err := h.r.ReadString('\n')
+	if line == "" && err != nil {
+		if !errors.Is(err, io.EOF) {
+			return fsWarn("fgets", err)
+		}
+		return false
+	}
+	return line
+}
+
+func builtinFread(env *Env, args []interface{}) interface{} {
+	h := argHandle(args[0], "fread")
+	// Panjang dari script tidak dipakai untuk alokasi langsung: buffer hanya
+	// tumbuh sebanyak data yang benar-benar terbaca
+	buf, err := io.ReadAll(io.LimitReader(h.r, int64(max(toInt(args[1]), 0))))
+	if len(buf) == 0 && err != nil {
+		return fsWarn("fread", err)
+	}
+	return string(buf)
+}
+
+func builtinFwrite(env *Env, args []interface{}) interface{} {
+	h := argHandle(args[0], "fwrite")
+	// Buang data yang sudah di-buffer supaya tulisan jatuh tepat setelah
+	// bagian yang terakhir dibaca (mode r+ / w+)
+	if n := h.r.Buffered(); n > 0 {
+		h.f.Seek(-int64(n), io.SeekCurrent)
+		h.r.Reset(h.f)
+	}
+	n, err := h.f.WriteString(argString(args[1]))
+	if err != nil {
+		return fsWarn("fwrite", err)
+	}
+	return float64(n)
+}
+
+func builtinFeof(env *Env, args []interface{}) interface{} {
+	h := argHandle(args[0], "feof")
+	_, err := h.r.Peek(1)
+	return err != nil
+}
+
+func builtinFclose(env *Env, args []interface{}) interface{} {
+	h := argHandle(args[0], "fclose")
+	if h.std {
+		return true
+	}
+	h.closed = true
+	return h.f.Close() == nil
+}
@@ -0,0 +1,90 @@
+package monyet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fsEnv membuat folder app (root filesystem script) dan secret.txt di
+// luarnya.
+func fsEnv(t *testing.T) (*Env, string) {
+	t.Helper()
+	dir := t.TempDir()
+	app := filepath.Join(dir, "app")
+	os.Mkdir(app, 0755)
+	os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("RAHASIA"), 0644)
+	os.WriteFile(filepath.Join(app, "abc.txt"), []byte("abc"), 0644)
+	if err := os.Symlink(filepath.Join(dir, "secret.txt"), filepath.Join(app, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+	env := NewEnv()
+	env.SetVar("__BASE_DIR__", app)
+	return env, dir
+}
+
+// tryEval menjalankan ekspresi dan mengembalikan pesan panic-nya, kalau ada.
+func tryEval(env *Env, src string) (val interface{}, err string) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Sprint(r)
+		}
+	}()
+	return evalIn(env, src), ""
+}
+
+func TestFilesystemConfinedToRoot(t *testing.T) {
+	env, dir := fsEnv(t)
+	app := filepath.Join(dir, "app")
+
+	if got, err := tryEval(env, `file_get_contents("abc.txt")`); got != "abc" {
+		t.Errorf("file di dalam root = %#v, %q", got, err)
+	}
+	if got, err := tryEval(env, `file_get_contents("`+filepath.Join(app, "abc.txt")+`")`); got != "abc" {
+		t.Errorf("path absolut di dalam root = %#v, %q", got, err)
+	}
+	if got, err := tryEval(env, `file_put_contents("sub/../baru.txt", "x")`); err != "" || got == false {
+		t.Errorf("tulis di dalam root = %#v, %q", got, err)
+	}
+
+	escapes := []string{
+		`file_get_contents("../secret.txt")`,
+		`file_get_contents("a/../../secret.txt")`,
+		`file_get_contents("` + filepath.Join(dir, "secret.txt") + `")`,
+		`file_put_contents("../bocor.txt", "x")`,
+		`fopen("../secret.txt", "r")`,
+		`file_exists("../secret.txt")`,
+	}
+	for _, src := range escapes {
+		if _, err := tryEval(env, src); !strings.Contains(err, "akses ditolak") {
+			t.Errorf("%s seharusnya ditolak, error = %q", src, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bocor.txt")); err == nil {
+		t.Error("file_put_contents menulis di luar root")
+	}
+
+	// symlink di dalam root yang menunjuk keluar ditolak oleh os.Root
+	if got, _ := tryEval(env, `file_get_contents("link.txt")`); got != false {
+		t.Errorf("symlink keluar root = %#v, want false", got)
+	}
+}
+
+func TestFreadLengthIsNotPreallocated(t *testing.T) {
+	env, _ := fsEnv(t)
+	runIn(env, `$f = fopen("abc.txt", "r");`)
+	if got := evalIn(env, `fread($f, 9000000000000)`); got != "abc" {
+		t.Errorf("fread besar = %#v", got)
+	}
+	if got := evalIn(env, `fread($f, 10)`); got != "" {
+		t.Errorf("fread di EOF = %#v", got)
+	}
+	runIn(env, `fclose($f);`)
+}
+
+// runIn menjalankan beberapa statement di env yang sudah ada.
+func runIn(env *Env, src string) {
+	Eval(NewParser(NewLexer(src)).Parse(), env)
+}
@@ -0,0 +1,304 @@
+package monyet
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/pbkdf2"
+	crand "crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha3"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password di-hash dengan bcrypt, formatnya sama dengan PHP ($2y$12$...)
+// jadi hash dari aplikasi PHP bisa langsung diverifikasi. PBKDF2-SHA256
+// ($pbkdf2-sha256$i=600000$<salt>$<hash>) masih didukung untuk hash lama.
+//
+// Batas atas cost/iterasi juga berlaku saat verifikasi, supaya hash buatan
+// orang dengan cost raksasa tidak bisa dipakai untuk menghabiskan CPU.
+const (
+	passwordBcrypt  = "2y"
+	passwordCost    = 12
+	passwordMaxCost = 16
+	bcryptMaxLen    = 72 // byte; password lebih panjang ditolak
+
+	passwordPBKDF2        = "pbkdf2-sha256"
+	passwordIterations    = 600000
+	passwordMaxIterations = 10 * passwordIterations
+	passwordSaltLen       = 16
+	passwordKeyLen        = 32
+)
+
+var hashAlgos = map[string]func() hash.Hash{
+	"md5":        md5.New,
+	"sha1":       sha1.New,
+	"sha224":     sha256.New224,
+	"sha256":     sha256.New,
+	"sha384":     sha512.New384,
+	"sha512":     sha512.New,
+	"sha512/256": sha512.New512_256,
+	"sha3-256":   func() hash.Hash { return sha3.New256() },
+	"sha3-512":   func() hash.Hash { return sha3.New512() },
+	"crc32b":     func() hash.Hash { return crc32.NewIEEE() },
+}
+
+func init() {
+	predefinedConsts["PASSWORD_DEFAULT"] = passwordBcrypt
+	predefinedConsts["PASSWORD_BCRYPT"] = passwordBcrypt
+	predefinedConsts["PASSWORD_PBKDF2"] = passwordPBKDF2
+
+	RegisterBuiltin(Builtin{Name: "md5", MinArgs: 1, MaxArgs: 2, Returns: "string", Fn: fixedHash("md5")})
+	RegisterBuiltin(Builtin{Name: "sha1", MinArgs: 1, MaxArgs: 2, Returns: "string", Fn: fixedHash("sha1")})
+	RegisterBuiltin(Builtin{Name: "crc32", MinArgs: 1, MaxArgs: 1, Returns: "int", Fn: builtinCrc32})
+	RegisterBuiltin(Builtin{Name: "hash", MinArgs: 2, MaxArgs: 3, Params: []string{"string"}, Returns: "string", Fn: builtinHash})
+	RegisterBuiltin(Builtin{Name: "hash_algos", MinArgs: 0, MaxArgs: 0, Returns: "array", Fn: builtinHashAlgos})
+	RegisterBuiltin(Builtin{Name: "hash_hmac", MinArgs: 3, MaxArgs: 4, Params: []string{"string"}, Returns: "string", Fn: builtinHashHmac})
+	RegisterBuiltin(Builtin{Name: "hash_equals", MinArgs: 2, MaxArgs: 2, Params: []string{"string", "string"}, Returns: "bool", Fn: builtinHashEquals})
+	RegisterBuiltin(Builtin{Name: "password_hash", MinArgs: 1, MaxArgs: 3, Params: []string{"string"}, Fn: builtinPasswordHash})
+	RegisterBuiltin(Builtin{Name: "password_verify", MinArgs: 2, MaxArgs: 2, Params: []string{"string", "string"}, Returns: "bool", Fn: builtinPasswordVerify})
+	RegisterBuiltin(Builtin{Name: "base64_encode", MinArgs: 1, MaxArgs: 1, Returns: "string", Fn: builtinBase64Encode})
+	RegisterBuiltin(Builtin{Name: "base64_decode", MinArgs: 1, MaxArgs: 2, Params: []string{"string"}, Fn: builtinBase64Decode})
+	RegisterBuiltin(Builtin{Name: "bin2hex", MinArgs: 1, MaxArgs: 1, Returns: "string", Fn: builtinBin2Hex})
+	RegisterBuiltin(Builtin{Name: "hex2bin", MinArgs: 1, MaxArgs: 1, Params: []string{"string"}, Fn: builtinHex2Bin})
+	RegisterBuiltin(Builtin{Name: "random_bytes", MinArgs: 1, MaxArgs: 1, Params: []string{"int"}, Returns: "string", Fn: builtinRandomBytes})
+	RegisterBuiltin(Builtin{Name: "uuid", MinArgs: 0, MaxArgs: 0, Returns: "string", Fn: builtinUUID})
+}
+
+func lookupHash(algo string) func() hash.Hash {
+	h, ok := hashAlgos[strings.ToLower(algo)]
+	if !ok {
+		panic(fmt.Sprintf("hash: algoritma %q tidak didukung", algo))
+	}
+	return h
+}
+
+// digest menghitung hash data. Hasilnya hex, atau byte mentah kalau binary.
+func digest(newHash func() hash.Hash, data string, binary bool) string {
+	h := newHash()
+	h.Write([]byte(data))
+	sum := h.Sum(nil)
+	if binary {
+		return string(sum)
+	}
+	return hex.EncodeToString(sum)
+}
+
+func binaryArg(args []interface{}, i int) bool {
+	return len(args) > i && isTruthy(args[i])
+}
+
+func fixedHash(algo string) BuiltinFunc {
+	return func(env *Env, args []interface{}) interface{} {
+		return digest(hashAlgos[algo], argString(args[0]), binaryArg(args, 1))
+	}
+}
+
+func builtinCrc32(env *Env, args []interface{}) interface{} {
+	return float64(crc32.ChecksumIEEE([]byte(argString(args[0]))))
+}
+
+// builtinHash: hash("sha256", $data) atau hash("sha256", $data, true).
+func builtinHash(env *Env, args []interface{}) interface{} {
+	return digest(lookupHash(args[0].(string)), argString(args[1]), binaryArg(args, 2))
+}
+
+func builtinHashAlgos(env *Env, args []interface{}) interface{} {
+	names := make([]string, 0, len(hashAlgos))
+	for name := range hashAlgos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make([]interface{}, len(names))
+	for i, name := range names {
+		out[i] = name
+	}
+	return out
+}
+
+// builtinHashHmac: hash_hmac("sha256", $payload, $secret).
+func builtinHashHmac(env *Env, args []interface{}) interface{} {
+	newHash := lookupHash(args[0].(string))
+	mac := hmac.New(newHash, []byte(argString(args[2])))
+	mac.Write([]byte(argString(args[1])))
+	sum := mac.Sum(nil)
+	if binaryArg(args, 3) {
+		return string(sum)
+	}
+	return hex.EncodeToString(sum)
+}
+
+// builtinHashEquals membandingkan dua string dalam waktu konstan, dipakai
+// untuk token dan signature supaya tidak bocor lewat timing.
+func builtinHashEquals(env *Env, args []interface{}) interface{} {
+	return subtle.ConstantTimeCompare([]byte(args[0].(string)), []byte(args[1].(string))) == 1
+}
+
+// builtinPasswordHash: password_hash($pw), password_hash($pw,
+// PASSWORD_DEFAULT, ["cost" => 13]) atau password_hash($pw, PASSWORD_PBKDF2,
+// ["iterations" => 1000000]). Salt acak dibuat otomatis dan disimpan di
+// string hasilnya.
+func builtinPasswordHash(env *Env, args []interface{}) interface{} {
+	algo := passwordBcrypt
+	if len(args) > 1 && args[1] != nil {
+		algo = argString(args[1])
+	}
+	opts := map[string]interface{}{}
+	if len(args) > 2 {
+		if m, ok := args[2].(map[string]interface{}); ok {
+			opts = m
+		}
+	}
+
+	switch algo {
+	case passwordBcrypt:
+		cost := passwordCost
+		if n, ok := opts["cost"]; ok {
+			cost = toInt(n)
+		}
+		if cost < bcrypt.MinCost || cost > passwordMaxCost {
+			panic(fmt.Sprintf("password_hash(): cost harus %d sampai %d", bcrypt.MinCost, passwordMaxCost))
+		}
+		// Password yang terlalu panjang biasanya datang dari input user,
+		// jadi cukup gagal dengan false, bukan panic
+		if len(args[0].(string)) > bcryptMaxLen {
+			fmt.Fprintln(os.Stderr, "Warning: password_hash(): password lebih dari 72 byte, tidak bisa di-hash dengan bcrypt")
+			return false
+		}
+		h, err := bcrypt.GenerateFromPassword([]byte(args[0].(string)), cost)
+		if err != nil {
+			panic("password_hash(): " + err.Error())
+		}
+		// Go menulis $2a$, PHP $2y$; algoritmanya sama
+		return "$" + passwordBcrypt + strings.TrimPrefix(string(h), "$2a")
+
+	case passwordPBKDF2:
+		iter := passwordIterations
+		if n, ok := opts["iterations"]; ok {
+			iter = toInt(n)
+		}
+		if iter < 1000 || iter > passwordMaxIterations {
+			panic(fmt.Sprintf("password_hash(): iterations harus 1000 sampai %d", passwordMaxIterations))
+		}
+		salt := make([]byte, passwordSaltLen)
+		crand.Read(salt)
+		key := passwordKey(args[0].(string), salt, iter)
+		enc := base64.RawStdEncoding
+		return fmt.Sprintf("$%s$i=%d$%s$%s", passwordPBKDF2, iter, enc.EncodeToString(salt), enc.EncodeToString(key))
+	}
+	panic(fmt.Sprintf("password_hash(): algoritma %v tidak didukung", args[1]))
+}
+
+func passwordKey(password string, salt []byte, iter int) []byte {
+	key, err := pbkdf2.Key(sha256.New, password, salt, iter, passwordKeyLen)
+	if err != nil {
+		panic("password_hash(): " + err.Error())
+	}
+	return key
+}
+
+// builtinPasswordVerify mengembalikan false untuk hash yang formatnya tidak
+// dikenal, bukan panic, karena nilainya biasanya datang dari database.
+func builtinPasswordVerify(env *Env, args []interface{}) interface{} {
+	password, hashed := args[0].(string), args[1].(string)
+	if strings.HasPrefix(hashed, "$2") {
+		// Password di atas 72 byte tidak pernah bisa di-hash, jadi jangan
+		// cocokkan dengan hash dari 72 byte pertamanya
+		cost, err := bcrypt.Cost([]byte(hashed))
+		if err != nil || cost > passwordMaxCost || len(password) > bcryptMaxLen {
+			return false
+		}
+		return bcrypt.CompareHashAndPassword([]byte(hashed), []byte(password)) == nil
+	}
+
+	parts := strings.Split(hashed, "$")
+	// "", algo, "i=N", salt, hash
+	if len(parts) != 5 || parts[1] != passwordPBKDF2 || !strings.HasPrefix(parts[2], "i=") {
+		return false
+	}
+	iter, err := strconv.Atoi(parts[2][2:])
+	if err != nil || iter < 1 || iter > passwordMaxIterations {
+		return false
+	}
+	enc := base64.RawStdEncoding
+	salt, err1 := enc.DecodeString(parts[3])
+	want, err2 := enc.DecodeString(parts[4])
+	if err1 != nil || err2 != nil || len(want) == 0 || len(want) > 64 {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, password, salt, iter, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+func builtinBase64Encode(env *Env, args []interface{}) interface{} {
+	return base64.StdEncoding.EncodeToString([]byte(argString(args[0])))
+}
+
+// builtinBase64Decode menerima base64 standar maupun URL-safe, dengan atau
+// tanpa padding. Input yang tidak valid menghasilkan false.
+func builtinBase64Decode(env *Env, args []interface{}) interface{} {
+	s := strings.TrimRight(strings.TrimSpace(args[0].(string)), "=")
+	enc := base64.RawStdEncoding
+	if strings.ContainsAny(s, "-_") {
+		enc = base64.RawURLEncoding
+	}
+	b, err := enc.DecodeString(s)
+	if err != nil {
+		return false
+	}
+	return string(b)
+}
+
+func builtinBin2Hex(env *Env, args []interface{}) interface{} {
+	return hex.EncodeToString([]byte(argString(args[0])))
+}
+
+func builtinHex2Bin(env *Env, args []interface{}) interface{} {
+	b, err := hex.DecodeString(args[0].(string))
+	if err != nil {
+		return false
+	}
+	return string(b)
+}
+
+// builtinRandomBytes mengembalikan byte acak dari crypto/rand sebagai
+// string biner; biasanya dibungkus bin2hex() atau base64_encode().
+func builtinRandomBytes(env *Env, args []interface{}) interface{} {
+	n := toInt(args[0])
+	if n < 1 {
+		panic("random_bytes(): panjang minimal 1")
+	}
+	b := make([]byte, n)
+	crand.Read(b)
+	return string(b)
+}
+
+// builtinUUID membuat UUID versi 4 (acak).
+func builtinUUID(env *Env, args []interface{}) interface{} {
+	return newUUID()
+}
+
+// newUUID membuat UUID versi 4 acak.
+func newUUID() string {
+	var b [16]byte
+	crand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // versi 4
+	b[8] = b[8]&0x3f | 0x80 // varian RFC 4122
+	h := hex.EncodeToString(b[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
@@ -0,0 +1,76 @@
+package monyet
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPasswordHashBcrypt(t *testing.T) {
+	env := NewEnv()
+	h := builtinPasswordHash(env, []interface{}{"rahasia", nil, map[string]interface{}{"cost": 4.0}}).(string)
+	if !strings.HasPrefix(h, "$2y$04$") {
+		t.Fatalf("hash %q bukan bcrypt $2y$", h)
+	}
+	if builtinPasswordVerify(env, []interface{}{"rahasia", h}) != true {
+		t.Error("password benar ditolak")
+	}
+	if builtinPasswordVerify(env, []interface{}{"salah", h}) != false {
+		t.Error("password salah diterima")
+	}
+}
+
+func TestPasswordVerifyPHPHash(t *testing.T) {
+	// Contoh dari dokumentasi password_verify() PHP
+	hash := "$2y$10$.vGA1O9wmRjrwAVXD98HNOgsNpDczlqm3Jq7KnEd1rVAGv3Fykk1a"
+	if builtinPasswordVerify(NewEnv(), []interface{}{"rasmuslerdorf", hash}) != true {
+		t.Error("hash PHP tidak terverifikasi")
+	}
+}
+
+func TestPasswordVerifyPBKDF2(t *testing.T) {
+	env := NewEnv()
+	h := builtinPasswordHash(env, []interface{}{"rahasia", passwordPBKDF2, map[string]interface{}{"iterations": 1000.0}}).(string)
+	if builtinPasswordVerify(env, []interface{}{"rahasia", h}) != true {
+		t.Error("hash PBKDF2 lama harus tetap bisa diverifikasi")
+	}
+}
+
+func TestPasswordVerifyRejectsHugeCost(t *testing.T) {
+	env := NewEnv()
+	crafted := []string{
+		"$pbkdf2-sha256$i=2000000000$c2FsdA$aGFzaA",
+		"$2y$31$.vGA1O9wmRjrwAVXD98HNOgsNpDczlqm3Jq7KnEd1rVAGv3Fykk1a",
+	}
+	for _, h := range crafted {
+		start := time.Now()
+		if builtinPasswordVerify(env, []interface{}{"x", h}) != false {
+			t.Errorf("%s diterima", h)
+		}
+		if d := time.Since(start); d > time.Second {
+			t.Errorf("%s butuh %v, batas cost tidak berlaku", h, d)
+		}
+	}
+}
+
+func TestPasswordHashTooLong(t *testing.T) {
+	env := NewEnv()
+	long := strings.Repeat("a", 100)
+	if got := builtinPasswordHash(env, []interface{}{long, nil, map[string]interface{}{"cost": 4.0}}); got != false {
+		t.Errorf("password 100 byte = %#v, want false", got)
+	}
+	// tepat 72 byte masih boleh
+	h := builtinPasswordHash(env, []interface{}{long[:72], nil, map[string]interface{}{"cost": 4.0}}).(string)
+	if builtinPasswordVerify(env, []interface{}{long[:72], h}) != true {
+		t.Error("password 72 byte ditolak")
+	}
+	// verify dengan password panjang cukup false, tidak panic
+	if builtinPasswordVerify(env, []interface{}{long, h}) != false {
+		t.Error("password 100 byte diterima")
+	}
+	// PBKDF2 tidak punya batas 72 byte
+	p := builtinPasswordHash(env, []interface{}{long, passwordPBKDF2, map[string]interface{}{"iterations": 1000.0}}).(string)
+	if builtinPasswordVerify(env, []interface{}{long, p}) != true {
+		t.Error("password panjang dengan PBKDF2 ditolak")
+	}
+}
@@ -0,0 +1,236 @@
+package monyet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// requestContext adalah state satu request HTTP. Dipasang di Env handler
+// dan diwariskan ke semua scope di bawahnya, jadi header() atau
+// http_response_code() bisa dipanggil dari function mana pun yang dipanggil
+// handler.
+type requestContext struct {
+	w      http.ResponseWriter
+	r      *http.Request
+	status int
+	// File sementara hasil upload, dihapus setelah handler selesai
+	uploads []string
+
+	// Overlay isolasi request (lihat env.go): variabel scope bersama yang
+	// ditulis atau disalin, function dan konstanta yang dibuat di request ini
+	globals map[*Env]map[string]interface{}
+	funcs   map[string]Function
+	consts  map[string]interface{}
+}
+
+// HTTPResponse adalah hasil response(): body, status dan header sekaligus.
+type HTTPResponse struct {
+	Status  int
+	Headers map[string]string
+	Body    interface{}
+}
+
+func (r *HTTPResponse) String() string {
+	return fmt.Sprintf("Response(%d)", r.Status)
+}
+
+func init() {
+	RegisterBuiltin(Builtin{Name: "http_response_code", MinArgs: 0, MaxArgs: 1, Returns: "int", Fn: builtinHTTPResponseCode})
+	RegisterBuiltin(Builtin{Name: "header", MinArgs: 1, MaxArgs: 3, Params: []string{"string", "bool", "int"}, Fn: builtinHeader})
+	RegisterBuiltin(Builtin{Name: "setcookie", MinArgs: 1, MaxArgs: 7, Params: []string{"string"}, Returns: "bool", Fn: builtinSetcookie})
+	RegisterBuiltin(Builtin{Name: "redirect", MinArgs: 1, MaxArgs: 2, Params: []string{"string", "int"}, Returns: "string", Fn: builtinRedirect})
+	RegisterBuiltin(Builtin{Name: "response", MinArgs: 0, MaxArgs: 3, Params: []string{"", "int", "array"}, Fn: builtinResponse})
+}
+
+// currentRequest mengembalikan request yang sedang dilayani, atau panic
+// kalau fungsi HTTP dipanggil di luar handler serve.
+func currentRequest(env *Env, fnName string) *requestContext {
+	if env.req == nil {
+		panic(fmt.Sprintf("%s() hanya bisa dipakai di dalam handler serve", fnName))
+	}
+	return env.req
+}
+
+func validStatus(code int, fnName string) int {
+	if code < 100 || code > 999 {
+		panic(fmt.Sprintf("%s(): status %d tidak valid", fnName, code))
+	}
+	return code
+}
+
+// builtinHTTPResponseCode: http_response_code(404) men-set status dan
+// mengembalikan status sebelumnya; tanpa argumen hanya membaca.
+func builtinHTTPResponseCode(env *Env, args []interface{}) interface{} {
+	ctx := currentRequest(env, "http_response_code")
+	prev := ctx.status
+	if len(args) > 0 {
+		ctx.status = validStatus(toInt(args[0]), "http_response_code")
+	}
+	return float64(prev)
+}
+
+// builtinHeader: header("Content-Type: text/plain"). Argumen kedua false
+// berarti menambah header (bukan mengganti), argumen ketiga men-set status.
+// Header Location tanpa status eksplisit menjadikan response 302, seperti PHP.
+func builtinHeader(env *Env, args []interface{}) interface{} {
+	ctx := currentRequest(env, "header")
+	name, value, ok := strings.Cut(args[0].(string), ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		panic(fmt.Sprintf("header(): format harus \"Nama: nilai\", dapat %q", args[0]))
+	}
+	value = strings.TrimSpace(value)
+
+	replace := len(args) < 2 || args[1] == nil || args[1].(bool)
+	if replace {
+		ctx.w.Header().Set(name, value)
+	} else {
+		ctx.w.Header().Add(name, value)
+	}
+
+	if len(args) > 2 && args[2] != nil {
+		ctx.status = validStatus(toInt(args[2]), "header")
+	} else if strings.EqualFold(name, "Location") && ctx.status == http.StatusOK {
+		ctx.status = http.StatusFound
+	}
+	return nil
+}
+
+// builtinSetcookie mendukung dua bentuk:
+// setcookie("sesi", $id, ["expires" => time() + 3600, "httponly" => true])
+// setcookie("sesi", $id, $expires, $path, $domain, $secure, $httponly)
+func builtinSetcookie(env *Env, args []interface{}) interface{} {
+	ctx := currentRequest(env, "setcookie")
+	c := &http.Cookie{Name: args[0].(string), Path: "/"}
+	if len(args) > 1 {
+		c.Value = argString(args[1])
+	}
+
+	opts := map[string]interface{}{}
+	if len(args) > 2 {
+		if m, ok := args[2].(map[string]interface{}); ok {
+			for k, v := range m {
+				opts[strings.ToLower(k)] = v
+			}
+		} else {
+			positional := []string{"expires", "path", "domain", "secure", "httponly"}
+			for i, key := range positional {
+				if 2+i < len(args) {
+					opts[key] = args[2+i]
+				}
+			}
+		}
+	}
+
+	if v, ok := opts["expires"]; ok && toNumber(v) > 0 {
+		c.Expires = time.Unix(int64(toNumber(v)), 0)
+	}
+	if v, ok := opts["max_age"]; ok {
+		c.MaxAge = toInt(v)
+	}
+	if v, ok := opts["path"]; ok && argString(v) != "" {
+		c.Path = argString(v)
+	}
+	if v, ok := opts["domain"]; ok {
+		c.Domain = argString(v)
+	}
+	if v, ok := opts["secure"]; ok {
+		c.Secure = isTruthy(v)
+	}
+	if v, ok := opts["httponly"]; ok {
+		c.HttpOnly = isTruthy(v)
+	}
+	if v, ok := opts["samesite"]; ok {
+		switch strings.ToLower(argString(v)) {
+		case "lax":
+			c.SameSite = http.SameSiteLaxMode
+		case "strict":
+			c.SameSite = http.SameSiteStrictMode
+		case "none":
+			c.SameSite = http.SameSiteNoneMode
+		}
+	}
+	// Value kosong berarti hapus cookie
+	if c.Value == "" {
+		c.MaxAge = -1
+	}
+
+	http.SetCookie(ctx.w, c)
+	return true
+}
+
+// builtinRedirect: return redirect("/login"); atau redirect($url, 301).
+func builtinRedirect(env *Env, args []interface{}) interface{} {
+	ctx := currentRequest(env, "redirect")
+	code := http.StatusFound
+	if len(args) > 1 {
+		code = validStatus(toInt(args[1]), "redirect")
+	}
+	ctx.w.Header().Set("Location", args[0].(string))
+	ctx.status = code
+	return ""
+}
+
+// builtinResponse: return response(["error" => "nama wajib diisi"], 400);
+// Header boleh diberikan sebagai map ["X-Trace" => $id].
+func builtinResponse(env *Env, args []interface{}) interface{} {
+	res := &HTTPResponse{Status: http.StatusOK, Headers: map[string]string{}}
+	if len(args) > 0 {
+		res.Body = args[0]
+	}
+	if len(args) > 1 && args[1] != nil {
+		res.Status = validStatus(toInt(args[1]), "response")
+	}
+	if len(args) > 2 {
+		if m, ok := args[2].(map[string]interface{}); ok {
+			for k, v := range m {
+				res.Headers[k] = argString(v)
+			}
+		}
+	}
+	return res
+}
+
+// writeResponse menulis hasil handler. Map dan array selalu dikirim sebagai
+// JSON. String yang isinya JSON valid (misalnya hasil json_encode) juga
+// diberi Content-Type JSON, selain itu HTML. Content-Type yang sudah di-set
+// lewat header() tidak ditimpa.
+func writeResponse(ctx *requestContext, result interface{}) {
+	h := ctx.w.Header()
+	if res, ok := result.(*HTTPResponse); ok {
+		for k, v := range res.Headers {
+			h.Set(k, v)
+		}
+		ctx.status = res.Status
+		result = res.Body
+	}
+
+	var body []byte
+	contentType := "text/html; charset=utf-8"
+	switch v := result.(type) {
+	case nil:
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(jsonValue(v))
+		if err != nil {
+			panic(fmt.Sprintf("response: gagal encode JSON: %v", err))
+		}
+		body, contentType = b, "application/json"
+	case string:
+		body = []byte(v)
+		trimmed := strings.TrimSpace(v)
+		if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(body) {
+			contentType = "application/json"
+		}
+	default:
+		body = []byte(formatValue(v))
+	}
+
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", contentType)
+	}
+	ctx.w.WriteHeader(ctx.status)
+	ctx.w.Write(body)
+}
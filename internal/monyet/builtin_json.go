@@ -0,0 +1,56 @@
+package monyet
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+func init() {
+	RegisterBuiltin(Builtin{Name: "json_encode", MinArgs: 1, MaxArgs: 1, Returns: "string", Fn: builtinJSONEncode})
+	RegisterBuiltin(Builtin{Name: "json_decode", MinArgs: 1, MaxArgs: 1, Params: []string{"string"}, Fn: builtinJSONDecode})
+}
+
+// jsonValue menyiapkan nilai untuk di-encode: array literal seperti [1, 2]
+// (map dengan key "0", "1", ...) menjadi JSON array, bukan object.
+func jsonValue(v interface{}) interface{} {
+	switch c := v.(type) {
+	case map[string]interface{}:
+		if isList(c) {
+			out := make([]interface{}, len(c))
+			for i := range out {
+				out[i] = jsonValue(c[strconv.Itoa(i)])
+			}
+			return out
+		}
+		out := make(map[string]interface{}, len(c))
+		for k, val := range c {
+			out[k] = jsonValue(val)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(c))
+		for i, val := range c {
+			out[i] = jsonValue(val)
+		}
+		return out
+	}
+	return v
+}
+
+func builtinJSONEncode(env *Env, args []interface{}) interface{} {
+	jsonBytes, err := json.Marshal(jsonValue(args[0]))
+	if err != nil {
+		return fmt.Sprintf(`{"error": "%v"}`, err)
+	}
+	return string(jsonBytes)
+}
+
+// builtinJSONDecode mengembalikan nil kalau input bukan JSON yang valid.
+func builtinJSONDecode(env *Env, args []interface{}) interface{} {
+	var result interface{}
+	if err := json.Unmarshal([]byte(args[0].(string)), &result); err != nil {
+		return nil
+	}
+	return result
+}
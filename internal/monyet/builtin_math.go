@@ -0,0 +1,238 @@
+package monyet
+
+import (
+	crand "crypto/rand"
+	"fmt"
+	"math"
+	"math/big"
+	mrand "math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const randMax = 2147483647
+
+// seededRand dipakai rand()/mt_rand() setelah mt_srand() dipanggil, supaya
+// urutan angkanya bisa diulang. Sebelum itu semua angka acak diambil dari
+// crypto/rand.
+var seededRand struct {
+	mu  sync.Mutex
+	rng *mrand.Rand
+}
+
+func init() {
+	predefinedConsts["PI"] = math.Pi
+	predefinedConsts["M_PI"] = math.Pi
+	predefinedConsts["M_E"] = math.E
+	predefinedConsts["INF"] = math.Inf(1)
+	predefinedConsts["NAN"] = math.NaN()
+	predefinedConsts["PHP_INT_MAX"] = float64(math.MaxInt64)
+	predefinedConsts["PHP_FLOAT_EPSILON"] = math.Nextafter(1, 2) - 1
+
+	// Angka bulat bertipe int, jadi fungsi yang hasilnya bisa bulat atau
+	// pecahan tidak menyebut tipe return supaya int $r = round(2.6) lolos check
+	RegisterBuiltin(Builtin{Name: "abs", MinArgs: 1, MaxArgs: 1, Fn: mathFunc(math.Abs)})
+	RegisterBuiltin(Builtin{Name: "floor", MinArgs: 1, MaxArgs: 1, Returns: "int", Fn: mathFunc(math.Floor)})
+	RegisterBuiltin(Builtin{Name: "ceil", MinArgs: 1, MaxArgs: 1, Returns: "int", Fn: mathFunc(math.Ceil)})
+	RegisterBuiltin(Builtin{Name: "sqrt", MinArgs: 1, MaxArgs: 1, Fn: mathFunc(math.Sqrt)})
+	RegisterBuiltin(Builtin{Name: "round", MinArgs: 1, MaxArgs: 2, Fn: builtinRound})
+	RegisterBuiltin(Builtin{Name: "pow", MinArgs: 2, MaxArgs: 2, Fn: builtinPow})
+	RegisterBuiltin(Builtin{Name: "intdiv", MinArgs: 2, MaxArgs: 2, Returns: "int", Fn: builtinIntdiv})
+	RegisterBuiltin(Builtin{Name: "fmod", MinArgs: 2, MaxArgs: 2, Fn: builtinFmod})
+	RegisterBuiltin(Builtin{Name: "min", MinArgs: 1, MaxArgs: -1, Fn: minMax(-1)})
+	RegisterBuiltin(Builtin{Name: "max", MinArgs: 1, MaxArgs: -1, Fn: minMax(1)})
+	RegisterBuiltin(Builtin{Name: "number_format", MinArgs: 1, MaxArgs: 4, Returns: "string", Fn: builtinNumberFormat})
+	RegisterBuiltin(Builtin{Name: "intval", MinArgs: 1, MaxArgs: 1, Returns: "int", Fn: mathFunc(math.Trunc)})
+	RegisterBuiltin(Builtin{Name: "floatval", MinArgs: 1, MaxArgs: 1, Returns: "float", Fn: mathFunc(func(x float64) float64 { return x })})
+	RegisterBuiltin(Builtin{Name: "is_nan", MinArgs: 1, MaxArgs: 1, Returns: "bool", Fn: builtinIsNaN})
+	RegisterBuiltin(Builtin{Name: "rand", MinArgs: 0, MaxArgs: 2, Returns: "int", Fn: builtinRand})
+	RegisterBuiltin(Builtin{Name: "mt_rand", MinArgs: 0, MaxArgs: 2, Returns: "int", Fn: builtinRand})
+	RegisterBuiltin(Builtin{Name: "mt_srand", MinArgs: 0, MaxArgs: 1, Fn: builtinMtSrand})
+	RegisterBuiltin(Builtin{Name: "getrandmax", MinArgs: 0, MaxArgs: 0, Returns: "int", Fn: func(env *Env, args []interface{}) interface{} {
+		return float64(randMax)
+	}})
+	RegisterBuiltin(Builtin{Name: "random_int", MinArgs: 2, MaxArgs: 2, Returns: "int", Fn: builtinRandomInt})
+}
+
+func mathFunc(f func(float64) float64) BuiltinFunc {
+	return func(env *Env, args []interface{}) interface{} {
+		return f(toNumber(args[0]))
+	}
+}
+
+// roundHalfUp membulatkan menjauhi nol seperti round() di PHP:
+// round(2.5) = 3, round(-2.5) = -3, round(12.3456, 2) = 12.35.
+func roundHalfUp(x float64, precision int) float64 {
+	if math.IsInf(x, 0) || math.IsNaN(x) {
+		return x
+	}
+	pow := math.Pow(10, float64(precision))
+	// Pre-rounding ke 15 digit signifikan supaya 1.005 * 100 (= 100.4999..)
+	// tetap dibulatkan ke 101, sama seperti PHP
+	scaled, _ := strconv.ParseFloat(strconv.FormatFloat(x*pow, 'g', 15, 64), 64)
+	return math.Round(scaled) / pow
+}
+
+func builtinRound(env *Env, args []interface{}) interface{} {
+	precision := 0
+	if len(args) > 1 {
+		precision = toInt(args[1])
+	}
+	return roundHalfUp(toNumber(args[0]), precision)
+}
+
+func builtinPow(env *Env, args []interface{}) interface{} {
+	return math.Pow(toNumber(args[0]), toNumber(args[1]))
+}
+
+func builtinIntdiv(env *Env, args []interface{}) interface{} {
+	a, b := int64(toNumber(args[0])), int64(toNumber(args[1]))
+	if b == 0 {
+		panic("pembagian dengan nol")
+	}
+	return float64(a / b)
+}
+
+func builtinFmod(env *Env, args []interface{}) interface{} {
+	return math.Mod(toNumber(args[0]), toNumber(args[1]))
+}
+
+func builtinIsNaN(env *Env, args []interface{}) interface{} {
+	return math.IsNaN(toNumber(args[0]))
+}
+
+// minMax menerima beberapa argumen atau satu array: max(1, 5) / max($list).
+func minMax(sign int) BuiltinFunc {
+	return func(env *Env, args []interface{}) interface{} {
+		vals := args
+		if len(args) == 1 {
+			vals = listValues(args[0])
+		}
+		if len(vals) == 0 {
+			panic("min()/max() butuh minimal satu nilai")
+		}
+		best := vals[0]
+		for _, v := range vals[1:] {
+			if compareValues(v, best)*sign > 0 {
+				best = v
+			}
+		}
+		return best
+	}
+}
+
+// builtinNumberFormat: number_format(1234.567, 2) = "1,234.57",
+// number_format(1234.5, 2, ",", ".") = "1.234,50".
+func builtinNumberFormat(env *Env, args []interface{}) interface{} {
+	decimals := 0
+	if len(args) > 1 {
+		decimals = max(toInt(args[1]), 0)
+	}
+	decPoint, thousands := ".", ","
+	if len(args) > 2 {
+		decPoint = argString(args[2])
+	}
+	if len(args) > 3 {
+		thousands = argString(args[3])
+	}
+
+	x := roundHalfUp(toNumber(args[0]), decimals)
+	s := strconv.FormatFloat(math.Abs(x), 'f', decimals, 64)
+	intPart, fracPart, _ := strings.Cut(s, ".")
+
+	var sb strings.Builder
+	if x < 0 {
+		sb.WriteString("-")
+	}
+	for i, ch := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			sb.WriteString(thousands)
+		}
+		sb.WriteRune(ch)
+	}
+	if decimals > 0 {
+		sb.WriteString(decPoint)
+		sb.WriteString(fracPart)
+	}
+	return sb.String()
+}
+
+// cryptoIntn mengembalikan angka acak aman di [0, n).
+func cryptoIntn(n int64) int64 {
+	v, err := crand.Int(crand.Reader, big.NewInt(n))
+	if err != nil {
+		panic("random: " + err.Error())
+	}
+	return v.Int64()
+}
+
+// maxExactInt adalah bilangan bulat terbesar yang masih tepat di float64.
+const maxExactInt = 1 << 53
+
+// randomBound mengubah argumen batas menjadi int64. Batas di luar ±2^53
+// ditolak, karena angka acak di sekitarnya tidak bisa disimpan tepat
+// sebagai angka MonyetLang.
+func randomBound(fnName string, v interface{}) int64 {
+	f := math.Trunc(toNumber(v))
+	if math.IsNaN(f) || f > maxExactInt || f < -maxExactInt {
+		panic(fmt.Sprintf("%s(): batas %s di luar rentang -2^53..2^53", fnName, formatValue(toNumber(v))))
+	}
+	return int64(f)
+}
+
+func randomBetween(lo, hi int64, seeded bool) int64 {
+	if hi < lo {
+		lo, hi = hi, lo
+	}
+	// Lebar rentang dihitung sebagai uint64 supaya tidak overflow
+	n := uint64(hi) - uint64(lo) + 1
+	if seeded {
+		seededRand.mu.Lock()
+		defer seededRand.mu.Unlock()
+		if seededRand.rng != nil {
+			return lo + int64(seededRand.rng.Uint64N(n))
+		}
+	}
+	v, err := crand.Int(crand.Reader, new(big.Int).SetUint64(n))
+	if err != nil {
+		panic("random: " + err.Error())
+	}
+	return lo + int64(v.Uint64())
+}
+
+// builtinRand: rand() 0..getrandmax(), rand($max) 0..$max, rand($min, $max).
+func builtinRand(env *Env, args []interface{}) interface{} {
+	lo, hi := int64(0), int64(randMax)
+	switch len(args) {
+	case 1:
+		hi = randomBound("rand", args[0])
+	case 2:
+		lo, hi = randomBound("rand", args[0]), randomBound("rand", args[1])
+	}
+	return float64(randomBetween(lo, hi, true))
+}
+
+// builtinMtSrand mengaktifkan generator yang bisa diulang. Tanpa seed,
+// seed diambil acak dari crypto/rand.
+func builtinMtSrand(env *Env, args []interface{}) interface{} {
+	var seed uint64
+	if len(args) > 0 {
+		seed = uint64(int64(toNumber(args[0])))
+	} else {
+		seed = uint64(cryptoIntn(math.MaxInt64))
+	}
+	seededRand.mu.Lock()
+	seededRand.rng = mrand.New(mrand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+	seededRand.mu.Unlock()
+	return nil
+}
+
+// builtinRandomInt selalu memakai crypto/rand, cocok untuk token dan OTP.
+func builtinRandomInt(env *Env, args []interface{}) interface{} {
+	lo, hi := randomBound("random_int", args[0]), randomBound("random_int", args[1])
+	if lo > hi {
+		panic("random_int(): min tidak boleh lebih besar dari max")
+	}
+	return float64(randomBetween(lo, hi, false))
+}
@@ -0,0 +1,84 @@
+package monyet
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMathBuiltins(t *testing.T) {
+	tests := []struct {
+		src  string
+		want interface{}
+	}{
+		{`abs(-3.5)`, 3.5},
+		{`floor(2.7)`, 2.0},
+		{`ceil(2.1)`, 3.0},
+		{`round(2.5)`, 3.0},
+		{`round(-2.5)`, -3.0},
+		{`round(12.3456, 2)`, 12.35},
+		{`intdiv(7, 2)`, 3.0},
+		{`fmod(7, 3)`, 1.0},
+		{`max(1, 9, 4)`, 9.0},
+		{`min([5, 2, 8])`, 2.0},
+		{`number_format(1234567.891, 2)`, "1,234,567.89"},
+		{`intval("42")`, 42.0},
+		{`intval(3.9)`, 3.0},
+	}
+	for _, tt := range tests {
+		if got := evalExpr(t, tt.src); got != tt.want {
+			t.Errorf("%s = %#v, want %#v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestRandomRanges(t *testing.T) {
+	t.Cleanup(func() {
+		seededRand.mu.Lock()
+		seededRand.rng = nil
+		seededRand.mu.Unlock()
+	})
+	tests := []struct {
+		src    string
+		lo, hi float64
+	}{
+		{`random_int(1, 3)`, 1, 3},
+		{`random_int(-5, -5)`, -5, -5},
+		{`random_int(-9007199254740992, 9007199254740992)`, -9007199254740992, 9007199254740992},
+		{`rand(5)`, 0, 5},
+		{`rand(10, 1)`, 1, 10},
+		{`mt_rand()`, 0, 2147483647},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 200; i++ {
+			got := evalExpr(t, tt.src).(float64)
+			if got < tt.lo || got > tt.hi || typeOf(got) != "int" {
+				t.Fatalf("%s = %v, di luar %v..%v", tt.src, got, tt.lo, tt.hi)
+			}
+		}
+	}
+
+	// setelah mt_srand() urutannya bisa diulang
+	evalExpr(t, `mt_srand(42)`)
+	first := []interface{}{evalExpr(t, `rand(1, 1000000)`), evalExpr(t, `mt_rand(-9007199254740992, 9007199254740992)`)}
+	evalExpr(t, `mt_srand(42)`)
+	second := []interface{}{evalExpr(t, `rand(1, 1000000)`), evalExpr(t, `mt_rand(-9007199254740992, 9007199254740992)`)}
+	if first[0] != second[0] || first[1] != second[1] {
+		t.Errorf("mt_srand(42) tidak bisa diulang: %v vs %v", first, second)
+	}
+}
+
+func TestRandomRejectsInexactBounds(t *testing.T) {
+	for _, src := range []string{
+		`random_int(-9000000000000000000, 9000000000000000000)`,
+		`random_int(0, 9007199254740994)`,
+		`rand(-9007199254740994, 0)`,
+	} {
+		_, err := tryEval(NewEnv(), src)
+		if !strings.Contains(err, "di luar rentang") {
+			t.Errorf("%s error = %q", src, err)
+		}
+	}
+	if _, err := tryEval(NewEnv(), `random_int(3, 1)`); !strings.Contains(err, "min tidak boleh") {
+		t.Errorf("random_int(3, 1) error = %q", err)
+	}
+}
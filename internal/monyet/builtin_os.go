@@ -0,0 +1,99 @@
+package monyet
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ExitSignal dipakai exit() untuk menghentikan script. Panic ini ditangkap
+// oleh runner (cmd/monyet) yang lalu keluar dengan Code, atau oleh handler
+// serve yang cukup mengakhiri request-nya saja.
+type ExitSignal struct {
+	Code int
+}
+
+func init() {
+	predefinedConsts["STDIN"] = &FileHandle{name: "php://stdin", f: os.Stdin, r: bufio.NewReader(os.Stdin), std: true}
+	predefinedConsts["STDOUT"] = &FileHandle{name: "php://stdout", f: os.Stdout, r: bufio.NewReader(os.Stdout), std: true}
+	predefinedConsts["STDERR"] = &FileHandle{name: "php://stderr", f: os.Stderr, r: bufio.NewReader(os.Stderr), std: true}
+
+	RegisterBuiltin(Builtin{Name: "getenv", MinArgs: 0, MaxArgs: 1, Fn: builtinGetenv})
+	RegisterBuiltin(Builtin{Name: "putenv", MinArgs: 1, MaxArgs: 1, Params: []string{"string"}, Returns: "bool", Fn: builtinPutenv})
+	RegisterBuiltin(Builtin{Name: "stream_get_contents", MinArgs: 1, MaxArgs: 1, Fn: builtinStreamGetContents})
+	RegisterBuiltin(Builtin{Name: "exit", MinArgs: 0, MaxArgs: 1, Fn: builtinExit})
+	RegisterBuiltin(Builtin{Name: "die", MinArgs: 0, MaxArgs: 1, Fn: builtinExit})
+}
+
+// SetScriptGlobals mengisi $argv, $argc dan $_ENV untuk script yang
+// dijalankan dari command line. args[0] adalah path script.
+func SetScriptGlobals(env *Env, args []string) {
+	argv := make([]interface{}, len(args))
+	for i, a := range args {
+		argv[i] = a
+	}
+	env.SetVar("argv", argv)
+	env.SetVar("argc", float64(len(args)))
+	env.SetVar("_ENV", environMap())
+}
+
+func environMap() map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		out[k] = v
+	}
+	return out
+}
+
+// builtinGetenv: getenv("HOME") mengembalikan string atau false kalau tidak
+// di-set, getenv() tanpa argumen mengembalikan semua variabel.
+func builtinGetenv(env *Env, args []interface{}) interface{} {
+	if len(args) == 0 {
+		return environMap()
+	}
+	v, ok := os.LookupEnv(argString(args[0]))
+	if !ok {
+		return false
+	}
+	return v
+}
+
+// builtinPutenv: putenv("KEY=value") men-set, putenv("KEY") menghapus.
+func builtinPutenv(env *Env, args []interface{}) interface{} {
+	k, v, hasValue := strings.Cut(args[0].(string), "=")
+	if k == "" {
+		panic("putenv(): nama variabel kosong")
+	}
+	if !hasValue {
+		return os.Unsetenv(k) == nil
+	}
+	return os.Setenv(k, v) == nil
+}
+
+// builtinStreamGetContents membaca sisa isi handle sampai habis, misalnya
+// stream_get_contents(STDIN) untuk script yang dipakai sebagai filter.
+func builtinStreamGetContents(env *Env, args []interface{}) interface{} {
+	h := argHandle(args[0], "stream_get_contents")
+	b, err := io.ReadAll(h.r)
+	if err != nil {
+		return fsWarn("stream_get_contents", err)
+	}
+	return string(b)
+}
+
+// builtinExit: exit(1) keluar dengan status 1, exit("pesan") mencetak pesan
+// lalu keluar dengan status 0, sama seperti PHP.
+func builtinExit(env *Env, args []interface{}) interface{} {
+	code := 0
+	if len(args) > 0 {
+		if msg, ok := args[0].(string); ok {
+			fmt.Print(msg)
+		} else {
+			code = toInt(args[0])
+		}
+	}
+	panic(ExitSignal{Code: code})
+}
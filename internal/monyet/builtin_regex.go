@@ -0,0 +1,379 @@
+package monyet
+
+import (
+	"container/list"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	pregPatternOrder = 1
+	pregSetOrder     = 2
+	pregSplitNoEmpty = 1
+)
+
+// regexCacheSize membatasi jumlah pola yang disimpan. Pola yang dibangun
+// dari input user ("/" + $q + "/") bisa berbeda di setiap request, jadi
+// cache tanpa batas akan terus membesar.
+const regexCacheSize = 256
+
+// regexCache adalah LRU kecil: pola PHP ("/.../i") -> *regexp.Regexp.
+var regexCache = struct {
+	mu    sync.Mutex
+	order *list.List // depan = paling baru dipakai
+	items map[string]*list.Element
+}{order: list.New(), items: map[string]*list.Element{}}
+
+type regexEntry struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func cachedRegex(pattern string) (*regexp.Regexp, bool) {
+	regexCache.mu.Lock()
+	defer regexCache.mu.Unlock()
+	el, ok := regexCache.items[pattern]
+	if !ok {
+		return nil, false
+	}
+	regexCache.order.MoveToFront(el)
+	return el.Value.(*regexEntry).re, true
+}
+
+func storeRegex(pattern string, re *regexp.Regexp) {
+	regexCache.mu.Lock()
+	defer regexCache.mu.Unlock()
+	if el, ok := regexCache.items[pattern]; ok {
+		regexCache.order.MoveToFront(el)
+		return
+	}
+	regexCache.items[pattern] = regexCache.order.PushFront(&regexEntry{pattern, re})
+	if regexCache.order.Len() > regexCacheSize {
+		oldest := regexCache.order.Back()
+		regexCache.order.Remove(oldest)
+		delete(regexCache.items, oldest.Value.(*regexEntry).pattern)
+	}
+}
+
+func init() {
+	predefinedConsts["PREG_PATTERN_ORDER"] = float64(pregPatternOrder)
+	predefinedConsts["PREG_SET_ORDER"] = float64(pregSetOrder)
+	predefinedConsts["PREG_SPLIT_NO_EMPTY"] = float64(pregSplitNoEmpty)
+
+	RegisterBuiltin(Builtin{Name: "preg_match", MinArgs: 2, MaxArgs: 3, Params: []string{"string", "string"}, Returns: "int", Refs: []int{2}, Fn: builtinPregMatch})
+	RegisterBuiltin(Builtin{Name: "preg_match_all", MinArgs: 2, MaxArgs: 4, Params: []string{"string", "string"}, Returns: "int", Refs: []int{2}, Fn: builtinPregMatchAll})
+	RegisterBuiltin(Builtin{Name: "preg_replace", MinArgs: 3, MaxArgs: 4, Params: []string{"string", "string", "string"}, Returns: "string", Fn: builtinPregReplace})
+	RegisterBuiltin(Builtin{Name: "preg_replace_callback", MinArgs: 3, MaxArgs: 4, Params: []string{"string", "callable", "string"}, Returns: "string", Fn: builtinPregReplaceCallback})
+	RegisterBuiltin(Builtin{Name: "preg_split", MinArgs: 2, MaxArgs: 4, Params: []string{"string", "string"}, Returns: "array", Fn: builtinPregSplit})
+	RegisterBuiltin(Builtin{Name: "preg_quote", MinArgs: 1, MaxArgs: 2, Params: []string{"string"}, Returns: "string", Fn: builtinPregQuote})
+}
+
+// compileRegex menerjemahkan pola gaya PHP ("/^\d+$/i") ke regexp Go.
+// Delimiter bebas (/, #, ~, ...), modifier yang didukung: i, m, s, x, U, u.
+// Hasil kompilasi disimpan di cache (maksimal regexCacheSize pola) supaya
+// handler yang dipanggil berulang tidak mengompilasi pola yang sama terus.
+func compileRegex(pattern string) *regexp.Regexp {
+	if re, ok := cachedRegex(pattern); ok {
+		return re
+	}
+
+	p := strings.TrimSpace(pattern)
+	if p == "" {
+		panic("regex: pola kosong")
+	}
+	open := p[0]
+	if open == '\\' || (open >= 'a' && open <= 'z') || (open >= 'A' && open <= 'Z') || (open >= '0' && open <= '9') {
+		panic(fmt.Sprintf("regex: delimiter tidak valid di %q", pattern))
+	}
+	closing := open
+	switch open {
+	case '(':
+		closing = ')'
+	case '{':
+		closing = '}'
+	case '[':
+		closing = ']'
+	case '<':
+		closing = '>'
+	}
+	end := strings.LastIndexByte(p, closing)
+	if end <= 0 {
+		panic(fmt.Sprintf("regex: delimiter penutup %q tidak ada di %q", closing, pattern))
+	}
+	body, mods := p[1:end], p[end+1:]
+
+	var flags strings.Builder
+	for _, m := range mods {
+		switch m {
+		case 'i', 'm', 's', 'U':
+			flags.WriteRune(m)
+		case 'x':
+			body = stripExtended(body)
+		case 'u':
+			// Regexp Go selalu UTF-8
+		default:
+			panic(fmt.Sprintf("regex: modifier %q tidak dikenal", m))
+		}
+	}
+	if flags.Len() > 0 {
+		body = "(?" + flags.String() + ")" + body
+	}
+
+	re, err := regexp.Compile(body)
+	if err != nil {
+		panic(fmt.Sprintf("regex: pola %s tidak valid: %v", pattern, err))
+	}
+	storeRegex(pattern, re)
+	return re
+}
+
+// stripExtended membuang spasi dan komentar # untuk modifier x, kecuali
+// yang di-escape atau berada di dalam [...].
+func stripExtended(s string) string {
+	var sb strings.Builder
+	inClass := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			sb.WriteByte(c)
+			i++
+			sb.WriteByte(s[i])
+			continue
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case !inClass && (c == ' ' || c == '\t' || c == '\n' || c == '\r'):
+			continue
+		case !inClass && c == '#':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// matchGroups membentuk array hasil satu match. Tanpa named group hasilnya
+// list biasa, kalau ada named group hasilnya map berisi index angka dan
+// nama group sekaligus, seperti PHP.
+func matchGroups(re *regexp.Regexp, s string, loc []int) interface{} {
+	groups := make([]interface{}, re.NumSubexp()+1)
+	for i := range groups {
+		if loc[2*i] >= 0 {
+			groups[i] = s[loc[2*i]:loc[2*i+1]]
+		} else {
+			groups[i] = ""
+		}
+	}
+	if !hasNamedGroups(re) {
+		return groups
+	}
+	out := map[string]interface{}{}
+	for i, name := range re.SubexpNames() {
+		out[strconv.Itoa(i)] = groups[i]
+		if name != "" {
+			out[name] = groups[i]
+		}
+	}
+	return out
+}
+
+func hasNamedGroups(re *regexp.Regexp) bool {
+	for _, name := range re.SubexpNames() {
+		if name != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// builtinPregMatch: preg_match("/user\/(?<id>\d+)/", $PATH, $m) mengisi
+// $m["id"] dan mengembalikan 1, atau 0 kalau tidak cocok.
+func builtinPregMatch(env *Env, args []interface{}) interface{} {
+	re := compileRegex(args[0].(string))
+	s := args[1].(string)
+	loc := re.FindStringSubmatchIndex(s)
+	if len(args) > 2 {
+		if loc == nil {
+			args[2].(*Ref).Set([]interface{}{})
+		} else {
+			args[2].(*Ref).Set(matchGroups(re, s, loc))
+		}
+	}
+	if loc == nil {
+		return float64(0)
+	}
+	return float64(1)
+}
+
+// builtinPregMatchAll mengembalikan jumlah match. Dengan PREG_PATTERN_ORDER
+// (default) $m[0] berisi semua match penuh, $m[1] semua group pertama, dst.
+// Dengan PREG_SET_ORDER $m berisi satu array per match.
+func builtinPregMatchAll(env *Env, args []interface{}) interface{} {
+	re := compileRegex(args[0].(string))
+	s := args[1].(string)
+	locs := re.FindAllStringSubmatchIndex(s, -1)
+	if len(args) < 3 {
+		return float64(len(locs))
+	}
+
+	order := pregPatternOrder
+	if len(args) > 3 {
+		order = toInt(args[3])
+	}
+
+	if order == pregSetOrder {
+		sets := make([]interface{}, len(locs))
+		for i, loc := range locs {
+			sets[i] = matchGroups(re, s, loc)
+		}
+		args[2].(*Ref).Set(sets)
+		return float64(len(locs))
+	}
+
+	cols := make([]interface{}, re.NumSubexp()+1)
+	for g := range cols {
+		col := make([]interface{}, len(locs))
+		for i, loc := range locs {
+			if loc[2*g] >= 0 {
+				col[i] = s[loc[2*g]:loc[2*g+1]]
+			} else {
+				col[i] = ""
+			}
+		}
+		cols[g] = col
+	}
+	if !hasNamedGroups(re) {
+		args[2].(*Ref).Set(cols)
+		return float64(len(locs))
+	}
+	out := map[string]interface{}{}
+	for g, name := range re.SubexpNames() {
+		out[strconv.Itoa(g)] = cols[g]
+		if name != "" {
+			out[name] = cols[g]
+		}
+	}
+	args[2].(*Ref).Set(out)
+	return float64(len(locs))
+}
+
+// replaceLimit memanggil repl untuk maksimal limit match (-1 = semua).
+func replaceLimit(re *regexp.Regexp, s string, limit int, repl func(loc []int) string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringSubmatchIndex(s, limit) {
+		sb.WriteString(s[last:loc[0]])
+		sb.WriteString(repl(loc))
+		last = loc[1]
+	}
+	sb.WriteString(s[last:])
+	return sb.String()
+}
+
+func replaceLimitArg(args []interface{}) int {
+	if len(args) > 3 && args[3] != nil {
+		return toInt(args[3])
+	}
+	return -1
+}
+
+// expandReplacement mengganti $1, \1 dan ${1} di string pengganti dengan
+// isi group yang bersangkutan.
+func expandReplacement(repl, s string, loc []int) string {
+	group := func(n int) string {
+		if 2*n+1 >= len(loc) || loc[2*n] < 0 {
+			return ""
+		}
+		return s[loc[2*n]:loc[2*n+1]]
+	}
+	var sb strings.Builder
+	for i := 0; i < len(repl); i++ {
+		c := repl[i]
+		if (c == '$' || c == '\\') && i+1 < len(repl) {
+			j := i + 1
+			braced := c == '$' && repl[j] == '{'
+			if braced {
+				j++
+			}
+			k := j
+			for k < len(repl) && k-j < 2 && repl[k] >= '0' && repl[k] <= '9' {
+				k++
+			}
+			if k > j && (!braced || (k < len(repl) && repl[k] == '}')) {
+				n, _ := strconv.Atoi(repl[j:k])
+				sb.WriteString(group(n))
+				if braced {
+					k++
+				}
+				i = k - 1
+				continue
+			}
+			if c == '\\' && repl[i+1] == '\\' {
+				sb.WriteByte('\\')
+				i++
+				continue
+			}
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+func builtinPregReplace(env *Env, args []interface{}) interface{} {
+	re := compileRegex(args[0].(string))
+	repl, s := args[1].(string), args[2].(string)
+	return replaceLimit(re, s, replaceLimitArg(args), func(loc []int) string {
+		return expandReplacement(repl, s, loc)
+	})
+}
+
+// builtinPregReplaceCallback memanggil callback dengan array match (sama
+// bentuknya dengan $m di preg_match) dan memakai hasilnya sebagai pengganti.
+func builtinPregReplaceCallback(env *Env, args []interface{}) interface{} {
+	re := compileRegex(args[0].(string))
+	s := args[2].(string)
+	return replaceLimit(re, s, replaceLimitArg(args), func(loc []int) string {
+		return argString(callValue(args[1], []interface{}{matchGroups(re, s, loc)}, env))
+	})
+}
+
+func builtinPregSplit(env *Env, args []interface{}) interface{} {
+	re := compileRegex(args[0].(string))
+	limit := -1
+	if len(args) > 2 && args[2] != nil && toInt(args[2]) > 0 {
+		limit = toInt(args[2])
+	}
+	flags := 0
+	if len(args) > 3 {
+		flags = toInt(args[3])
+	}
+
+	out := []interface{}{}
+	for _, part := range re.Split(args[1].(string), limit) {
+		if part == "" && flags&pregSplitNoEmpty != 0 {
+			continue
+		}
+		out = append(out, part)
+	}
+	return out
+}
+
+// builtinPregQuote meng-escape karakter khusus regex, plus delimiter kalau
+// diberikan.
+func builtinPregQuote(env *Env, args []interface{}) interface{} {
+	s := regexp.QuoteMeta(args[0].(string))
+	if len(args) > 1 {
+		if d := argString(args[1]); d != "" {
+			s = strings.ReplaceAll(s, d, `\`+d)
+		}
+	}
+	return s
+}
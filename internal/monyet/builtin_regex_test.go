@@ -0,0 +1,138 @@
+package monyet
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRegexDelimitersAndFlags(t *testing.T) {
+	tests := []struct {
+		src  string
+		want interface{}
+	}{
+		{`preg_match("/^\d+$/", "123")`, 1.0},
+		{`preg_match("#^a/b$#", "a/b")`, 1.0},
+		{`preg_match("~^x~", "xyz")`, 1.0},
+		{`preg_match("{^(a|b)+$}", "abba")`, 1.0},
+		{`preg_match("(^[0-9]+$)", "42")`, 1.0},
+		{`preg_match("<^y>", "yes")`, 1.0},
+		{`preg_match("  /a/  ", "a")`, 1.0},
+		{`preg_match("/ABC/", "abc")`, 0.0},
+		{`preg_match("/ABC/i", "abc")`, 1.0},
+		{`preg_match("/^b$/", "a" + PHP_EOL + "b")`, 0.0},
+		{`preg_match("/^b$/m", "a" + PHP_EOL + "b")`, 1.0},
+		{`preg_match("/a.b/", "a" + PHP_EOL + "b")`, 0.0},
+		{`preg_match("/a.b/s", "a" + PHP_EOL + "b")`, 1.0},
+		{`preg_match("/ a b  c # komentar/x", "abc")`, 1.0},
+		{`preg_match("/[ ]x/x", " x")`, 1.0},
+		{`preg_match("/^é.$/u", "éa")`, 1.0},
+		{`preg_replace("/a+/", "-", "baaad")`, "b-d"},
+		{`preg_replace("/a+/U", "-", "baaad")`, "b---d"},
+	}
+	for _, tt := range tests {
+		if got := evalExpr(t, tt.src); got != tt.want {
+			t.Errorf("%s = %#v, want %#v", tt.src, got, tt.want)
+		}
+	}
+
+	for src, want := range map[string]string{
+		`preg_match("", "a")`:      "pola kosong",
+		`preg_match("abc", "a")`:   "delimiter tidak valid",
+		`preg_match("\a\", "a")`:   "delimiter tidak valid",
+		`preg_match("/abc", "a")`:  "tidak ada",
+		`preg_match("{abc{", "a")`: "tidak ada",
+		`preg_match("/a/z", "a")`:  "modifier",
+		`preg_match("/a(/", "a")`:  "tidak valid",
+	} {
+		if _, err := tryEval(NewEnv(), src); !strings.Contains(err, want) {
+			t.Errorf("%s error = %q, want %q", src, err, want)
+		}
+	}
+}
+
+func TestPregMatchGroups(t *testing.T) {
+	env := runScript(t, `
+$n = preg_match("/user\/(?<id>\d+)/", "user/42", $m);
+$kosong = preg_match("/x/", "abc", $tidak);
+$jumlah = preg_match_all("/(\d)(\w)/", "1a 2b 3c", $kolom);
+preg_match_all("/(\d)(\w)/", "1a 2b", $set, PREG_SET_ORDER);
+`)
+	checks := map[string]interface{}{
+		`$n`:            1.0,
+		`$m["id"]`:      "42",
+		`$m[1]`:         "42",
+		`$m[0]`:         "user/42",
+		`$kosong`:       0.0,
+		`count($tidak)`: 0.0,
+		`$jumlah`:       3.0,
+		`$kolom[0][2]`:  "3c",
+		`$kolom[2][1]`:  "b",
+		`$set[1][0]`:    "2b",
+		`$set[1][1]`:    "2",
+	}
+	for src, want := range checks {
+		if got := evalIn(env, src); got != want {
+			t.Errorf("%s = %#v, want %#v", src, got, want)
+		}
+	}
+}
+
+func TestPregReplaceCallback(t *testing.T) {
+	tests := []struct {
+		src  string
+		want interface{}
+	}{
+		{`preg_replace_callback("/\d+/", fn($m) => intval($m[0]) * 2, "a1 b20 c3")`, "a2 b40 c6"},
+		{`preg_replace_callback("/(?<k>\w+)=(\w+)/", fn($m) => $m["k"] + ":" + $m[2], "a=1 b=2")`, "a:1 b:2"},
+		{`preg_replace_callback("/\d/", fn($m) => "#", "123", 2)`, "##3"},
+		{`preg_replace_callback("/x/", fn($m) => "y", "abc")`, "abc"},
+		{`preg_replace("/(\w+) (\w+)/", "$2 \1 ${1}", "hello world")`, "world hello hello"},
+	}
+	for _, tt := range tests {
+		if got := evalExpr(t, tt.src); got != tt.want {
+			t.Errorf("%s = %#v, want %#v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestPregSplit(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`preg_split("/[\s,]+/", "a, b  c,d")`, "a|b|c|d"},
+		{`preg_split("/,/", ",a,,b,")`, "|a||b|"},
+		{`preg_split("/,/", ",a,,b,", -1, PREG_SPLIT_NO_EMPTY)`, "a|b"},
+		{`preg_split("/,/", "a,b,c", 2)`, "a|b,c"},
+		{`preg_split("/,/", "a,b,c", 0)`, "a|b|c"},
+		{`preg_split("/,/", ",a,,b", null, PREG_SPLIT_NO_EMPTY)`, "a|b"},
+	}
+	for _, tt := range tests {
+		got := evalExpr(t, `implode("|", `+tt.src+`)`)
+		if got != tt.want {
+			t.Errorf("%s = %#v, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestRegexCacheIsBounded(t *testing.T) {
+	for i := 0; i < regexCacheSize*3; i++ {
+		compileRegex(fmt.Sprintf("/pola%d/", i))
+	}
+	regexCache.mu.Lock()
+	n, m := regexCache.order.Len(), len(regexCache.items)
+	regexCache.mu.Unlock()
+	if n > regexCacheSize || m != n {
+		t.Fatalf("cache berisi %d/%d pola, batas %d", n, m, regexCacheSize)
+	}
+
+	// pola yang sering dipakai tetap tinggal di cache
+	hot := compileRegex("/sering/")
+	for i := 0; i < regexCacheSize*2; i++ {
+		compileRegex(fmt.Sprintf("/lain%d/", i))
+		if compileRegex("/sering/") != hot {
+			t.Fatal("pola yang baru dipakai keluar dari cache")
+		}
+	}
+}
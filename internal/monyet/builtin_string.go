@@ -0,0 +1,505 @@
+package monyet
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Semua fungsi string bekerja per karakter (rune), bukan per byte, jadi
+// aman untuk teks UTF-8 seperti "héllo" atau "こんにちは".
+
+const (
+	strPadRight = 1
+	strPadLeft  = 0
+	strPadBoth  = 2
+)
+
+func init() {
+	predefinedConsts["STR_PAD_RIGHT"] = float64(strPadRight)
+	predefinedConsts["STR_PAD_LEFT"] = float64(strPadLeft)
+	predefinedConsts["STR_PAD_BOTH"] = float64(strPadBoth)
+
+	str := []string{"string"}
+	RegisterBuiltin(Builtin{Name: "strlen", MinArgs: 1, MaxArgs: 1, Returns: "int", Fn: builtinStrlen})
+	RegisterBuiltin(Builtin{Name: "substr", MinArgs: 2, MaxArgs: 3, Returns: "string", Fn: builtinSubstr})
+	RegisterBuiltin(Builtin{Name: "strpos", MinArgs: 2, MaxArgs: 3, Fn: builtinStrpos})
+	RegisterBuiltin(Builtin{Name: "stripos", MinArgs: 2, MaxArgs: 3, Fn: builtinStripos})
+	RegisterBuiltin(Builtin{Name: "str_replace", MinArgs: 3, MaxArgs: 3, Fn: builtinStrReplace})
+	RegisterBuiltin(Builtin{Name: "explode", MinArgs: 2, MaxArgs: 3, Returns: "array", Fn: builtinExplode})
+	RegisterBuiltin(Builtin{Name: "implode", MinArgs: 2, MaxArgs: 2, Returns: "string", Fn: builtinImplode})
+	RegisterBuiltin(Builtin{Name: "trim", MinArgs: 1, MaxArgs: 2, Returns: "string", Fn: trimFunc(strings.Trim, strings.TrimSpace)})
+	RegisterBuiltin(Builtin{Name: "ltrim", MinArgs: 1, MaxArgs: 2, Returns: "string", Fn: trimFunc(strings.TrimLeft, func(s string) string {
+		return strings.TrimLeftFunc(s, unicode.IsSpace)
+	})})
+	RegisterBuiltin(Builtin{Name: "rtrim", MinArgs: 1, MaxArgs: 2, Returns: "string", Fn: trimFunc(strings.TrimRight, func(s string) string {
+		return strings.TrimRightFunc(s, unicode.IsSpace)
+	})})
+	RegisterBuiltin(Builtin{Name: "strtolower", MinArgs: 1, MaxArgs: 1, Returns: "string", Fn: stringFunc(strings.ToLower)})
+	RegisterBuiltin(Builtin{Name: "strtoupper", MinArgs: 1, MaxArgs: 1, Returns: "string", Fn: stringFunc(strings.ToUpper)})
+	RegisterBuiltin(Builtin{Name: "ucfirst", MinArgs: 1, MaxArgs: 1, Returns: "string", Fn: stringFunc(ucfirst)})
+	RegisterBuiltin(Builtin{Name: "lcfirst", MinArgs: 1, MaxArgs: 1, Returns: "string", Fn: stringFunc(lcfirst)})
+	RegisterBuiltin(Builtin{Name: "ucwords", MinArgs: 1, MaxArgs: 1, Returns: "string", Fn: stringFunc(ucwords)})
+	RegisterBuiltin(Builtin{Name: "strrev", MinArgs: 1, MaxArgs: 1, Returns: "string", Fn: stringFunc(strrev)})
+	RegisterBuiltin(Builtin{Name: "sprintf", MinArgs: 1, MaxArgs: -1, Params: str, Returns: "string", Fn: builtinSprintf})
+	RegisterBuiltin(Builtin{Name: "printf", MinArgs: 1, MaxArgs: -1, Params: str, Returns: "int", Fn: builtinPrintf})
+	RegisterBuiltin(Builtin{Name: "str_pad", MinArgs: 2, MaxArgs: 4, Returns: "string", Fn: builtinStrPad})
+	RegisterBuiltin(Builtin{Name: "str_repeat", MinArgs: 2, MaxArgs: 2, Returns: "string", Fn: builtinStrRepeat})
+	RegisterBuiltin(Builtin{Name: "str_split", MinArgs: 1, MaxArgs: 2, Returns: "array", Fn: builtinStrSplit})
+	RegisterBuiltin(Builtin{Name: "str_contains", MinArgs: 2, MaxArgs: 2, Returns: "bool", Fn: stringPredicate(strings.Contains)})
+	RegisterBuiltin(Builtin{Name: "str_starts_with", MinArgs: 2, MaxArgs: 2, Returns: "bool", Fn: stringPredicate(strings.HasPrefix)})
+	RegisterBuiltin(Builtin{Name: "str_ends_with", MinArgs: 2, MaxArgs: 2, Returns: "bool", Fn: stringPredicate(strings.HasSuffix)})
+	RegisterBuiltin(Builtin{Name: "starts_with", MinArgs: 2, MaxArgs: 2, Returns: "bool", Fn: stringPredicate(strings.HasPrefix)})
+	RegisterBuiltin(Builtin{Name: "ends_with", MinArgs: 2, MaxArgs: 2, Returns: "bool", Fn: stringPredicate(strings.HasSuffix)})
+	RegisterBuiltin(Builtin{Name: "strcmp", MinArgs: 2, MaxArgs: 2, Returns: "int", Fn: builtinStrcmp})
+	RegisterBuiltin(Builtin{Name: "nl2br", MinArgs: 1, MaxArgs: 1, Returns: "string", Fn: stringFunc(func(s string) string {
+		return strings.ReplaceAll(s, "\n", "<br />\n")
+	})})
+	RegisterBuiltin(Builtin{Name: "htmlspecialchars", MinArgs: 1, MaxArgs: 1, Returns: "string", Fn: stringFunc(html.EscapeString)})
+	RegisterBuiltin(Builtin{Name: "strval", MinArgs: 1, MaxArgs: 1, Returns: "string", Fn: stringFunc(func(s string) string { return s })})
+}
+
+// stringFunc membungkus fungsi string -> string sederhana.
+func stringFunc(f func(string) string) BuiltinFunc {
+	return func(env *Env, args []interface{}) interface{} {
+		return f(argString(args[0]))
+	}
+}
+
+func stringPredicate(f func(s, sub string) bool) BuiltinFunc {
+	return func(env *Env, args []interface{}) interface{} {
+		return f(argString(args[0]), argString(args[1]))
+	}
+}
+
+// trimFunc: tanpa argumen kedua, trim spasi; dengan argumen kedua, trim
+// karakter-karakter yang disebutkan (seperti PHP).
+func trimFunc(withChars func(s, cutset string) string, spaces func(string) string) BuiltinFunc {
+	return func(env *Env, args []interface{}) interface{} {
+		if len(args) > 1 {
+			return withChars(argString(args[0]), argString(args[1]))
+		}
+		return spaces(argString(args[0]))
+	}
+}
+
+func builtinStrlen(env *Env, args []interface{}) interface{} {
+	return float64(utf8.RuneCountInString(argString(args[0])))
+}
+
+// runeRange menerjemahkan start/length ala PHP (boleh negatif) menjadi
+// batas slice [from, to) untuk n karakter.
+func runeRange(n, start int, length *int) (from, to int) {
+	if start < 0 {
+		start = max(n+start, 0)
+	}
+	if start > n {
+		return n, n
+	}
+	to = n
+	if length != nil {
+		l := *length
+		if l < 0 {
+			to = max(n+l, start)
+		} else {
+			to = min(start+l, n)
+		}
+	}
+	return start, to
+}
+
+func builtinSubstr(env *Env, args []interface{}) interface{} {
+	r := []rune(argString(args[0]))
+	var length *int
+	if len(args) > 2 && args[2] != nil {
+		l := toInt(args[2])
+		length = &l
+	}
+	from, to := runeRange(len(r), toInt(args[1]), length)
+	return string(r[from:to])
+}
+
+// strposFold mencari posisi needle dalam haystack (dalam karakter), mulai
+// dari offset. Mengembalikan false kalau tidak ketemu, sama seperti PHP.
+func strposFold(args []interface{}, fold bool) interface{} {
+	haystack := []rune(argString(args[0]))
+	needle := []rune(argString(args[1]))
+	offset := 0
+	if len(args) > 2 {
+		offset, _ = runeRange(len(haystack), toInt(args[2]), nil)
+	}
+	if fold {
+		haystack = []rune(strings.ToLower(string(haystack)))
+		needle = []rune(strings.ToLower(string(needle)))
+	}
+	idx := strings.Index(string(haystack[offset:]), string(needle))
+	if idx < 0 {
+		return false
+	}
+	return float64(offset + utf8.RuneCountInString(string(haystack[offset:])[:idx]))
+}
+
+func builtinStrpos(env *Env, args []interface{}) interface{} {
+	return strposFold(args, false)
+}
+
+func builtinStripos(env *Env, args []interface{}) interface{} {
+	return strposFold(args, true)
+}
+
+// builtinStrReplace mendukung search/replace berupa string maupun array,
+// dan subject berupa string atau array of string.
+func builtinStrReplace(env *Env, args []interface{}) interface{} {
+	var search, replace []string
+	if list := listValues(args[0]); list != nil {
+		for _, s := range list {
+			search = append(search, argString(s))
+		}
+	} else {
+		search = []string{argString(args[0])}
+	}
+	if list := listValues(args[1]); list != nil {
+		for _, s := range list {
+			replace = append(replace, argString(s))
+		}
+	} else {
+		for range search {
+			replace = append(replace, argString(args[1]))
+		}
+	}
+
+	apply := func(subject string) string {
+		for i, s := range search {
+			if s == "" {
+				continue
+			}
+			r := ""
+			if i < len(replace) {
+				r = replace[i]
+			}
+			subject = strings.ReplaceAll(subject, s, r)
+		}
+		return subject
+	}
+
+	if list := listValues(args[2]); list != nil {
+		out := make([]interface{}, len(list))
+		for i, s := range list {
+			out[i] = apply(argString(s))
+		}
+		return out
+	}
+	return apply(argString(args[2]))
+}
+
+func builtinExplode(env *Env, args []interface{}) interface{} {
+	sep := argString(args[0])
+	if sep == "" {
+		panic("explode(): delimiter tidak boleh kosong")
+	}
+	s := argString(args[1])
+
+	var parts []string
+	if len(args) > 2 {
+		limit := toInt(args[2])
+		switch {
+		case limit > 0:
+			parts = strings.SplitN(s, sep, limit)
+		case limit < 0:
+			parts = strings.Split(s, sep)
+			parts = parts[:max(len(parts)+limit, 0)]
+		default:
+			parts = strings.SplitN(s, sep, 1)
+		}
+	} else {
+		parts = strings.Split(s, sep)
+	}
+
+	out := make([]interface{}, len(parts))
+	for i, p := range parts {
+		out[i] = p
+	}
+	return out
+}
+
+func builtinImplode(env *Env, args []interface{}) interface{} {
+	glue, pieces := args[0], args[1]
+	// PHP juga menerima urutan terbalik: implode($arr, ",")
+	if _, isStr := glue.(string); !isStr {
+		glue, pieces = pieces, glue
+	}
+	list := listValues(pieces)
+	parts := make([]string, len(list))
+	for i, v := range list {
+		parts[i] = argString(v)
+	}
+	return strings.Join(parts, argString(glue))
+}
+
+func ucfirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+func lcfirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 {
+		return s
+	}
+	return string(unicode.ToLower(r)) + s[size:]
+}
+
+func ucwords(s string) string {
+	r := []rune(s)
+	for i := range r {
+		if i == 0 || unicode.IsSpace(r[i-1]) {
+			r[i] = unicode.ToUpper(r[i])
+		}
+	}
+	return string(r)
+}
+
+func strrev(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+func builtinStrPad(env *Env, args []interface{}) interface{} {
+	s := argString(args[0])
+	length := toInt(args[1])
+	pad := " "
+	if len(args) > 2 {
+		pad = argString(args[2])
+	}
+	padType := strPadRight
+	if len(args) > 3 {
+		padType = toInt(args[3])
+	}
+	return padString(s, length, pad, padType)
+}
+
+func padString(s string, length int, pad string, padType int) string {
+	missing := length - utf8.RuneCountInString(s)
+	if missing <= 0 || pad == "" {
+		return s
+	}
+	fill := func(n int) string {
+		padRunes := []rune(pad)
+		out := make([]rune, n)
+		for i := range out {
+			out[i] = padRunes[i%len(padRunes)]
+		}
+		return string(out)
+	}
+	switch padType {
+	case strPadLeft:
+		return fill(missing) + s
+	case strPadBoth:
+		left := missing / 2
+		return fill(left) + s + fill(missing-left)
+	}
+	return s + fill(missing)
+}
+
+func builtinStrRepeat(env *Env, args []interface{}) interface{} {
+	n := toInt(args[1])
+	if n < 0 {
+		panic("str_repeat(): jumlah pengulangan tidak boleh negatif")
+	}
+	return strings.Repeat(argString(args[0]), n)
+}
+
+func builtinStrSplit(env *Env, args []interface{}) interface{} {
+	r := []rune(argString(args[0]))
+	size := 1
+	if len(args) > 1 {
+		size = toInt(args[1])
+	}
+	if size < 1 {
+		panic("str_split(): panjang potongan minimal 1")
+	}
+	out := []interface{}{}
+	for i := 0; i < len(r); i += size {
+		out = append(out, string(r[i:min(i+size, len(r))]))
+	}
+	return out
+}
+
+func builtinStrcmp(env *Env, args []interface{}) interface{} {
+	return float64(strings.Compare(argString(args[0]), argString(args[1])))
+}
+
+func builtinSprintf(env *Env, args []interface{}) interface{} {
+	return phpSprintf(args[0].(string), args[1:])
+}
+
+func builtinPrintf(env *Env, args []interface{}) interface{} {
+	out := phpSprintf(args[0].(string), args[1:])
+	fmt.Print(out)
+	return float64(utf8.RuneCountInString(out))
+}
+
+// phpSprintf mengimplementasikan format ala PHP:
+// %[argnum$][flags][width][.precision]specifier
+// flags: - (rata kiri), + (selalu tampilkan tanda), 0 atau 'x (karakter padding).
+func phpSprintf(format string, args []interface{}) string {
+	var sb strings.Builder
+	r := []rune(format)
+	argIdx := 0
+
+	for i := 0; i < len(r); i++ {
+		if r[i] != '%' {
+			sb.WriteRune(r[i])
+			continue
+		}
+		i++
+		if i >= len(r) {
+			break
+		}
+		if r[i] == '%' {
+			sb.WriteRune('%')
+			continue
+		}
+
+		// argnum$
+		start := i
+		num := 0
+		for i < len(r) && unicode.IsDigit(r[i]) {
+			num = num*10 + int(r[i]-'0')
+			i++
+		}
+		argPos := -1
+		if i < len(r) && r[i] == '$' && i > start {
+			argPos = num - 1
+			i++
+		} else {
+			i = start
+		}
+
+		// flags
+		leftAlign, plus := false, false
+		padChar := ' '
+	flags:
+		for i < len(r) {
+			switch r[i] {
+			case '-':
+				leftAlign = true
+			case '+':
+				plus = true
+			case '0':
+				padChar = '0'
+			case ' ':
+				padChar = ' '
+			case '\'':
+				if i+1 < len(r) {
+					i++
+					padChar = r[i]
+				}
+			default:
+				break flags
+			}
+			i++
+		}
+
+		width := 0
+		for i < len(r) && unicode.IsDigit(r[i]) {
+			width = width*10 + int(r[i]-'0')
+			i++
+		}
+		precision := -1
+		if i < len(r) && r[i] == '.' {
+			i++
+			precision = 0
+			for i < len(r) && unicode.IsDigit(r[i]) {
+				precision = precision*10 + int(r[i]-'0')
+				i++
+			}
+		}
+		if i >= len(r) {
+			break
+		}
+		spec := r[i]
+
+		if argPos < 0 {
+			argPos = argIdx
+			argIdx++
+		}
+		if argPos >= len(args) {
+			panic(fmt.Sprintf("sprintf(): argumen kurang, butuh minimal %d", argPos+1))
+		}
+		arg := args[argPos]
+
+		var body string
+		numeric := true
+		switch spec {
+		case 'd', 'u':
+			n := int64(toNumber(arg))
+			body = strconv.FormatInt(n, 10)
+			if plus && n >= 0 {
+				body = "+" + body
+			}
+		case 'f', 'F':
+			if precision < 0 {
+				precision = 6
+			}
+			n := toNumber(arg)
+			body = strconv.FormatFloat(n, 'f', precision, 64)
+			if plus && n >= 0 {
+				body = "+" + body
+			}
+		case 'e', 'E', 'g', 'G':
+			if precision < 0 {
+				precision = 6
+			}
+			body = strconv.FormatFloat(toNumber(arg), byte(spec), precision, 64)
+		case 'x':
+			body = strconv.FormatInt(int64(toNumber(arg)), 16)
+		case 'X':
+			body = strings.ToUpper(strconv.FormatInt(int64(toNumber(arg)), 16))
+		case 'o':
+			body = strconv.FormatInt(int64(toNumber(arg)), 8)
+		case 'b':
+			body = strconv.FormatInt(int64(toNumber(arg)), 2)
+		case 'c':
+			body = string(rune(toInt(arg)))
+			numeric = false
+		case 's':
+			body = argString(arg)
+			if precision >= 0 {
+				if sr := []rune(body); precision < len(sr) {
+					body = string(sr[:precision])
+				}
+			}
+			numeric = false
+		default:
+			panic(fmt.Sprintf("sprintf(): format %%%c tidak dikenal", spec))
+		}
+
+		missing := width - utf8.RuneCountInString(body)
+		if missing > 0 {
+			fill := strings.Repeat(string(padChar), missing)
+			switch {
+			case leftAlign:
+				if padChar == '0' {
+					fill = strings.Repeat(" ", missing)
+				}
+				body += fill
+			case numeric && padChar == '0' && (strings.HasPrefix(body, "-") || strings.HasPrefix(body, "+")):
+				body = body[:1] + fill + body[1:]
+			default:
+				body = fill + body
+			}
+		}
+		sb.WriteString(body)
+	}
+	return sb.String()
+}
@@ -0,0 +1,100 @@
+package monyet
+
+import (
+	"reflect"
+	"testing"
+)
+
+// evalExpr menjalankan satu ekspresi Monyet di env baru.
+func evalExpr(t *testing.T, src string) interface{} {
+	t.Helper()
+	return evalNode(NewParser(NewLexer(src)).parseExpr(), NewEnv())
+}
+
+func TestStringBuiltins(t *testing.T) {
+	tests := []struct {
+		src  string
+		want interface{}
+	}{
+		{`strlen("héllo")`, 5.0},
+		{`substr("monyet", 1, 3)`, "ony"},
+		{`substr("monyet", -3)`, "yet"},
+		{`strpos("monyet", "y")`, 3.0},
+		{`strpos("monyet", "z")`, false},
+		{`stripos("MONYET", "nye")`, 2.0},
+		{`str_replace("a", "o", "banana")`, "bonono"},
+		{`explode(",", "a,b,c")`, []interface{}{"a", "b", "c"}},
+		{`implode("-", ["a", "b", "c"])`, "a-b-c"},
+		{`implode(["a", 1, 2.5], ",")`, "a,1,2.5"},
+		{`trim("  hi  ")`, "hi"},
+		{`trim("xxhixx", "x")`, "hi"},
+		{`ltrim("  hi ")`, "hi "},
+		{`rtrim(" hi  ")`, " hi"},
+		{`strtoupper("héllo")`, "HÉLLO"},
+		{`strtolower("MONYET")`, "monyet"},
+		{`ucfirst("monyet")`, "Monyet"},
+		{`lcfirst("Monyet")`, "monyet"},
+		{`ucwords("halo dunia")`, "Halo Dunia"},
+		{`strrev("こんにちは")`, "はちにんこ"},
+		{`str_pad("7", 3, "0", STR_PAD_LEFT)`, "007"},
+		{`str_pad("ab", 6, "-", STR_PAD_BOTH)`, "--ab--"},
+		{`str_repeat("ab", 3)`, "ababab"},
+		{`str_split("abcde", 2)`, []interface{}{"ab", "cd", "e"}},
+		{`str_contains("monyet", "nye")`, true},
+		{`str_starts_with("monyet", "mon")`, true},
+		{`str_ends_with("monyet", "mon")`, false},
+		{`strcmp("a", "b")`, -1.0},
+		{`nl2br("a" + PHP_EOL + "b")`, "a<br />\nb"},
+		{`htmlspecialchars("<a href='x'>&</a>")`, "&lt;a href=&#39;x&#39;&gt;&amp;&lt;/a&gt;"},
+		{`strval(1706670000)`, "1706670000"},
+	}
+	for _, tt := range tests {
+		if got := evalExpr(t, tt.src); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %#v, want %#v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestSprintf(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`sprintf("%s", 1706670000)`, "1706670000"},
+		{`sprintf("%s", 2.5)`, "2.5"},
+		{`sprintf("%d item", 3.9)`, "3 item"},
+		{`sprintf("%05.2f", 3.14159)`, "03.14"},
+		{`sprintf("%+d", 5)`, "+5"},
+		{`sprintf("%x %X %o %b", 255, 255, 8, 5)`, "ff FF 10 101"},
+		{`sprintf("%-5s|", "ab")`, "ab   |"},
+		{`sprintf("%'*8s", "ab")`, "******ab"},
+		{`sprintf("%.3s", "monyet")`, "mon"},
+		{`sprintf("%2$s %1$s", "dunia", "halo")`, "halo dunia"},
+		{`sprintf("100%%")`, "100%"},
+	}
+	for _, tt := range tests {
+		if got := evalExpr(t, tt.src); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestNumberFormatting(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`"ts: " + 1706670000`, "ts: 1706670000"},
+		{`"n: " + 1000000`, "n: 1000000"},
+		{`"f: " + 0.5`, "f: 0.5"},
+		{`"neg: " + -42`, "neg: -42"},
+	}
+	for _, tt := range tests {
+		if got := evalExpr(t, tt.src); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+	if got := formatValue(1e21); got != "1000000000000000000000" {
+		t.Errorf("formatValue(1e21) = %q", got)
+	}
+}
@@ -0,0 +1,62 @@
+package monyet
+
+import (
+	"strings"
+	"testing"
+)
+
+// runScript menjalankan script di env baru dan mengembalikan env-nya.
+func runScript(t *testing.T, src string) *Env {
+	t.Helper()
+	env := NewEnv()
+	Eval(NewParser(NewLexer(src)).Parse(), env)
+	return env
+}
+
+// evalIn menjalankan satu ekspresi di env yang sudah ada.
+func evalIn(env *Env, src string) interface{} {
+	return evalNode(NewParser(NewLexer(src)).parseExpr(), env)
+}
+
+const shadowScript = `
+function count($x) {
+    return "punyaku";
+}
+function date($format) {
+    return "tanggalku";
+}
+`
+
+func TestUserFunctionShadowsBuiltin(t *testing.T) {
+	env := runScript(t, shadowScript)
+	tests := []struct {
+		src  string
+		want interface{}
+	}{
+		{`count([1, 2])`, "punyaku"},
+		{`date("Y")`, "tanggalku"},
+		{`implode(",", array_map("count", [[1], [2, 3]]))`, "punyaku,punyaku"},
+		{`strlen("abc")`, 3.0},
+	}
+	for _, tt := range tests {
+		if got := evalIn(env, tt.src); got != tt.want {
+			t.Errorf("%s = %#v, want %#v", tt.src, got, tt.want)
+		}
+	}
+
+	// script lain tanpa deklarasi tetap memakai builtin
+	if got := evalExpr(t, `count([1, 2])`); got != 2.0 {
+		t.Errorf("count bawaan = %#v", got)
+	}
+}
+
+func TestCheckUserFunctionShadowsBuiltin(t *testing.T) {
+	src := shadowScript + `
+$n = strlen(count([1]));
+count([1], 2);
+`
+	errs := Check(NewParser(NewLexer(src)).Parse(), "app.nyet")
+	if len(errs) != 1 || !strings.Contains(errs[0], "count() butuh 1 argumen, dapat 2") {
+		t.Errorf("errs = %q", errs)
+	}
+}
@@ -0,0 +1,577 @@
+package monyet
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DateTime adalah nilai waktu yang membawa timezone-nya sendiri. Nilainya
+// tidak pernah diubah di tempat: date_modify dan date_timezone_set
+// mengembalikan DateTime baru.
+type DateTime struct {
+	t time.Time
+}
+
+func (d *DateTime) String() string {
+	return d.t.Format("2006-01-02 15:04:05")
+}
+
+func (d *DateTime) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.t.Format(time.RFC3339))), nil
+}
+
+var locationCache sync.Map // nama zona -> *time.Location
+
+func init() {
+	RegisterBuiltin(Builtin{Name: "time", MinArgs: 0, MaxArgs: 0, Returns: "int", Fn: builtinTime})
+	RegisterBuiltin(Builtin{Name: "microtime", MinArgs: 0, MaxArgs: 1, Fn: builtinMicrotime})
+	RegisterBuiltin(Builtin{Name: "date", MinArgs: 1, MaxArgs: 2, Params: []string{"string"}, Returns: "string", Fn: builtinDate})
+	RegisterBuiltin(Builtin{Name: "mktime", MinArgs: 0, MaxArgs: 6, Returns: "int", Fn: builtinMktime})
+	RegisterBuiltin(Builtin{Name: "strtotime", MinArgs: 1, MaxArgs: 2, Params: []string{"string"}, Fn: builtinStrtotime})
+	RegisterBuiltin(Builtin{Name: "date_diff", MinArgs: 2, MaxArgs: 2, Returns: "array", Fn: builtinDateDiff})
+	RegisterBuiltin(Builtin{Name: "sleep", MinArgs: 1, MaxArgs: 1, Returns: "int", Fn: builtinSleep})
+	RegisterBuiltin(Builtin{Name: "usleep", MinArgs: 1, MaxArgs: 1, Fn: builtinUsleep})
+	RegisterBuiltin(Builtin{Name: "date_default_timezone_set", MinArgs: 1, MaxArgs: 1, Params: []string{"string"}, Returns: "bool", Fn: builtinTimezoneSet})
+	RegisterBuiltin(Builtin{Name: "date_default_timezone_get", MinArgs: 0, MaxArgs: 0, Returns: "string", Fn: builtinTimezoneGet})
+	RegisterBuiltin(Builtin{Name: "date_create", MinArgs: 0, MaxArgs: 2, Fn: builtinDateCreate})
+	RegisterBuiltin(Builtin{Name: "date_format", MinArgs: 2, MaxArgs: 2, Params: []string{"", "string"}, Returns: "string", Fn: builtinDateFormat})
+	RegisterBuiltin(Builtin{Name: "date_modify", MinArgs: 2, MaxArgs: 2, Params: []string{"", "string"}, Fn: builtinDateModify})
+	RegisterBuiltin(Builtin{Name: "date_timezone_set", MinArgs: 2, MaxArgs: 2, Params: []string{"", "string"}, Fn: builtinDateTimezoneSet})
+	RegisterBuiltin(Builtin{Name: "date_timestamp_get", MinArgs: 1, MaxArgs: 1, Returns: "int", Fn: builtinDateTimestampGet})
+}
+
+// loadLocation memuat zona waktu dari database IANA ("Asia/Jakarta").
+func loadLocation(name string) *time.Location {
+	if loc, ok := locationCache.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(fmt.Sprintf("timezone tidak dikenal: %s", name))
+	}
+	locationCache.Store(name, loc)
+	return loc
+}
+
+// scriptLocation mengembalikan zona waktu default script. Bisa diganti
+// dengan date_default_timezone_set(), kalau tidak pakai zona lokal mesin.
+func scriptLocation(env *Env) *time.Location {
+	if tz, ok := env.GetVar("__TIMEZONE__"); ok {
+		if name, ok := tz.(string); ok && name != "" {
+			return loadLocation(name)
+		}
+	}
+	return time.Local
+}
+
+// toTime menerima timestamp (detik) atau DateTime. nil berarti sekarang.
+// Timestamp dalam string ("1706670000", juga "1.70667e+09" dari data lama
+// di MonyetDB) diperlakukan sama seperti angka.
+func toTime(v interface{}, loc *time.Location) time.Time {
+	switch t := v.(type) {
+	case nil:
+		return time.Now().In(loc)
+	case *DateTime:
+		return t.t
+	case string:
+		if n, err := strconv.ParseFloat(strings.TrimSpace(t), 64); err == nil {
+			v = n
+			break
+		}
+		if parsed, ok := parseTime(t, time.Now().In(loc), loc); ok {
+			return parsed
+		}
+		panic(fmt.Sprintf("format waktu tidak dikenal: %q", t))
+	}
+	sec, frac := math.Modf(toNumber(v))
+	return time.Unix(int64(sec), int64(frac*1e9)).In(loc)
+}
+
+func builtinTime(env *Env, args []interface{}) interface{} {
+	return float64(time.Now().Unix())
+}
+
+// builtinMicrotime: microtime() = "0.12345600 1700000000" seperti PHP,
+// microtime(true) = detik dalam float.
+func builtinMicrotime(env *Env, args []interface{}) interface{} {
+	now := time.Now()
+	if len(args) > 0 && isTruthy(args[0]) {
+		return float64(now.UnixNano()) / 1e9
+	}
+	usec := float64(now.Nanosecond()/1000) / 1e6
+	return fmt.Sprintf("%.8f %d", usec, now.Unix())
+}
+
+func builtinDate(env *Env, args []interface{}) interface{} {
+	loc := scriptLocation(env)
+	var ts interface{}
+	if len(args) > 1 {
+		ts = args[1]
+	}
+	return phpDate(args[0].(string), toTime(ts, loc).In(loc))
+}
+
+// builtinMktime: mktime(jam, menit, detik, bulan, tanggal, tahun). Argumen
+// yang tidak diisi memakai waktu sekarang.
+func builtinMktime(env *Env, args []interface{}) interface{} {
+	loc := scriptLocation(env)
+	now := time.Now().In(loc)
+	parts := []int{now.Hour(), now.Minute(), now.Second(), int(now.Month()), now.Day(), now.Year()}
+	for i, a := range args {
+		parts[i] = toInt(a)
+	}
+	t := time.Date(parts[5], time.Month(parts[3]), parts[4], parts[0], parts[1], parts[2], 0, loc)
+	return float64(t.Unix())
+}
+
+func builtinStrtotime(env *Env, args []interface{}) interface{} {
+	loc := scriptLocation(env)
+	base := time.Now().In(loc)
+	if len(args) > 1 {
+		base = toTime(args[1], loc)
+	}
+	t, ok := parseTime(args[0].(string), base, loc)
+	if !ok {
+		return false
+	}
+	return float64(t.Unix())
+}
+
+// builtinDateDiff mengembalikan selisih dua waktu sebagai map dengan key
+// y, m, d, h, i, s, days dan invert (1 kalau waktu kedua lebih awal).
+func builtinDateDiff(env *Env, args []interface{}) interface{} {
+	loc := scriptLocation(env)
+	a, b := toTime(args[0], loc), toTime(args[1], loc)
+	invert := 0.0
+	if b.Before(a) {
+		a, b = b, a
+		invert = 1
+	}
+	b = b.In(a.Location())
+
+	y := b.Year() - a.Year()
+	m := int(b.Month()) - int(a.Month())
+	d := b.Day() - a.Day()
+	h := b.Hour() - a.Hour()
+	i := b.Minute() - a.Minute()
+	s := b.Second() - a.Second()
+	if s < 0 {
+		s += 60
+		i--
+	}
+	if i < 0 {
+		i += 60
+		h--
+	}
+	if h < 0 {
+		h += 24
+		d--
+	}
+	// Pinjam jumlah hari dari bulan sebelum bulan b, lalu bulan sebelumnya
+	// lagi kalau masih kurang (31 Jan -> 1 Mar: Februari saja tidak cukup)
+	for k := 0; d < 0; k++ {
+		d += time.Date(b.Year(), b.Month()-time.Month(k), 0, 0, 0, 0, 0, a.Location()).Day()
+		m--
+	}
+	if m < 0 {
+		m += 12
+		y--
+	}
+
+	return map[string]interface{}{
+		"y":      float64(y),
+		"m":      float64(m),
+		"d":      float64(d),
+		"h":      float64(h),
+		"i":      float64(i),
+		"s":      float64(s),
+		"days":   math.Floor(b.Sub(a).Hours() / 24),
+		"invert": invert,
+	}
+}
+
+func builtinSleep(env *Env, args []interface{}) interface{} {
+	time.Sleep(time.Duration(toNumber(args[0]) * float64(time.Second)))
+	return float64(0)
+}
+
+func builtinUsleep(env *Env, args []interface{}) interface{} {
+	time.Sleep(time.Duration(toNumber(args[0])) * time.Microsecond)
+	return nil
+}
+
+// builtinTimezoneSet menyimpan zona default di scope paling luar, jadi
+// berlaku untuk seluruh script termasuk file include.
+func builtinTimezoneSet(env *Env, args []interface{}) interface{} {
+	name := args[0].(string)
+	loadLocation(name) // validasi dulu
+	// Di dalam request hanya berlaku untuk request itu
+	env.setVarIn(env.root(), "__TIMEZONE__", name)
+	return true
+}
+
+func builtinTimezoneGet(env *Env, args []interface{}) interface{} {
+	return scriptLocation(env).String()
+}
+
+// builtinDateCreate: date_create("2024-05-01 10:00", "Asia/Jakarta").
+func builtinDateCreate(env *Env, args []interface{}) interface{} {
+	loc := scriptLocation(env)
+	if len(args) > 1 && args[1] != nil {
+		loc = loadLocation(argString(args[1]))
+	}
+	s := "now"
+	if len(args) > 0 && args[0] != nil {
+		s = argString(args[0])
+	}
+	t, ok := parseTime(s, time.Now().In(loc), loc)
+	if !ok {
+		return false
+	}
+	return &DateTime{t: t}
+}
+
+func argDateTime(v interface{}, fnName string) *DateTime {
+	d, ok := v.(*DateTime)
+	if !ok {
+		panic(fmt.Sprintf("%s() butuh DateTime dari date_create()", fnName))
+	}
+	return d
+}
+
+func builtinDateFormat(env *Env, args []interface{}) interface{} {
+	return phpDate(args[1].(string), argDateTime(args[0], "date_format").t)
+}
+
+func builtinDateModify(env *Env, args []interface{}) interface{} {
+	d := argDateTime(args[0], "date_modify")
+	t, ok := parseTime(args[1].(string), d.t, d.t.Location())
+	if !ok {
+		return false
+	}
+	return &DateTime{t: t}
+}
+
+func builtinDateTimezoneSet(env *Env, args []interface{}) interface{} {
+	d := argDateTime(args[0], "date_timezone_set")
+	return &DateTime{t: d.t.In(loadLocation(args[1].(string)))}
+}
+
+func builtinDateTimestampGet(env *Env, args []interface{}) interface{} {
+	return float64(argDateTime(args[0], "date_timestamp_get").t.Unix())
+}
+
+// Format absolut yang dikenali strtotime/date_create.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"01/02/2006 15:04:05", // format Amerika m/d/Y, sama seperti PHP
+	"01/02/2006",
+	"02-01-2006 15:04:05", // d-m-Y
+	"02-01-2006",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	"2 January 2006",
+	"2 Jan 2006",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"15:04:05",
+	"15:04",
+}
+
+var relativePart = regexp.MustCompile(`^([+-]?\d+)\s*(sec|second|min|minute|hour|day|week|fortnight|month|year)s?\b`)
+
+// parseTime mengenali format waktu yang umum: tanggal absolut, "@ts",
+// kata kunci (now, today, tomorrow, yesterday, midnight, noon), dan
+// offset relatif seperti "+1 day", "-2 weeks", "3 hours ago" atau
+// "next month". Offset boleh disambung setelah tanggal/kata kunci.
+func parseTime(s string, base time.Time, loc *time.Location) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "@") {
+		sec, err := strconv.ParseFloat(s[1:], 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.Unix(int64(sec), 0).In(loc), true
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			if layout == "15:04:05" || layout == "15:04" {
+				y, m, d := base.Date()
+				t = time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, loc)
+			}
+			return t, true
+		}
+	}
+
+	rest := strings.ToLower(s)
+	t := base
+
+	// Awalan tanggal absolut diikuti offset: "2024-01-31 +1 month"
+	if head, tail, found := strings.Cut(rest, " "); found {
+		for _, layout := range timeLayouts {
+			if parsed, err := time.ParseInLocation(layout, head, loc); err == nil {
+				t, rest = parsed, tail
+				break
+			}
+		}
+	}
+
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		word, tail, _ := strings.Cut(rest, " ")
+		switch word {
+		case "now":
+			rest = tail
+			continue
+		case "today", "midnight":
+			t = startOfDay(t)
+			rest = tail
+			continue
+		case "tomorrow":
+			t = startOfDay(t).AddDate(0, 0, 1)
+			rest = tail
+			continue
+		case "yesterday":
+			t = startOfDay(t).AddDate(0, 0, -1)
+			rest = tail
+			continue
+		case "noon":
+			t = startOfDay(t).Add(12 * time.Hour)
+			rest = tail
+			continue
+		case "next", "last":
+			// "next monday" / "last friday": hari itu sesudah/sebelum hari ini
+			day, after, _ := strings.Cut(tail, " ")
+			if wd, ok := weekdayNames[day]; ok {
+				dir := 1
+				if word == "last" {
+					dir = -1
+				}
+				t = shiftWeekday(t, wd, dir)
+				rest = after
+				continue
+			}
+			n := "+1"
+			if word == "last" {
+				n = "-1"
+			}
+			rest = n + " " + tail
+		}
+
+		// "monday" saja berarti hari ini kalau memang Senin, selain itu Senin berikutnya
+		if wd, ok := weekdayNames[word]; ok {
+			t = shiftWeekday(t, wd, 0)
+			rest = tail
+			continue
+		}
+
+		// Jam di tengah ekspresi: "tomorrow 10:30"
+		if hm, err := time.Parse("15:04", word); err == nil {
+			t = time.Date(t.Year(), t.Month(), t.Day(), hm.Hour(), hm.Minute(), 0, 0, t.Location())
+			rest = tail
+			continue
+		}
+
+		m := relativePart.FindStringSubmatch(rest)
+		if m == nil {
+			return time.Time{}, false
+		}
+		n, _ := strconv.Atoi(m[1])
+		rest = rest[len(m[0]):]
+		if r := strings.TrimSpace(rest); r == "ago" || strings.HasPrefix(r, "ago ") {
+			n = -n
+			rest = strings.TrimPrefix(r, "ago")
+		}
+		t = addRelative(t, n, m[2])
+	}
+	return t, true
+}
+
+// weekdayNames: "monday" dan "mon" sampai "sunday" dan "sun".
+var weekdayNames = func() map[string]time.Weekday {
+	m := map[string]time.Weekday{}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		m[name], m[name[:3]] = d, d
+	}
+	return m
+}()
+
+// shiftWeekday pindah ke hari wd pukul 00:00. dir 1 = setelah hari ini,
+// -1 = sebelum hari ini, 0 = hari ini kalau cocok.
+func shiftWeekday(t time.Time, wd time.Weekday, dir int) time.Time {
+	t = startOfDay(t)
+	diff := (int(wd) - int(t.Weekday()) + 7) % 7
+	switch {
+	case dir > 0 && diff == 0:
+		diff = 7
+	case dir < 0:
+		diff -= 7
+	}
+	return t.AddDate(0, 0, diff)
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+func addRelative(t time.Time, n int, unit string) time.Time {
+	switch unit {
+	case "sec", "second":
+		return t.Add(time.Duration(n) * time.Second)
+	case "min", "minute":
+		return t.Add(time.Duration(n) * time.Minute)
+	case "hour":
+		return t.Add(time.Duration(n) * time.Hour)
+	case "day":
+		return t.AddDate(0, 0, n)
+	case "week":
+		return t.AddDate(0, 0, 7*n)
+	case "fortnight":
+		return t.AddDate(0, 0, 14*n)
+	case "month":
+		return t.AddDate(0, n, 0)
+	case "year":
+		return t.AddDate(n, 0, 0)
+	}
+	return t
+}
+
+// phpDate memformat waktu dengan kode format date() milik PHP. Karakter
+// yang diawali backslash ditulis apa adanya.
+func phpDate(format string, t time.Time) string {
+	var sb strings.Builder
+	r := []rune(format)
+	for i := 0; i < len(r); i++ {
+		c := r[i]
+		if c == '\\' && i+1 < len(r) {
+			i++
+			sb.WriteRune(r[i])
+			continue
+		}
+		switch c {
+		case 'd':
+			sb.WriteString(t.Format("02"))
+		case 'D':
+			sb.WriteString(t.Format("Mon"))
+		case 'j':
+			sb.WriteString(strconv.Itoa(t.Day()))
+		case 'l':
+			sb.WriteString(t.Format("Monday"))
+		case 'N':
+			wd := int(t.Weekday())
+			if wd == 0 {
+				wd = 7
+			}
+			sb.WriteString(strconv.Itoa(wd))
+		case 'S':
+			sb.WriteString(ordinalSuffix(t.Day()))
+		case 'w':
+			sb.WriteString(strconv.Itoa(int(t.Weekday())))
+		case 'z':
+			sb.WriteString(strconv.Itoa(t.YearDay() - 1))
+		case 'W':
+			_, week := t.ISOWeek()
+			sb.WriteString(fmt.Sprintf("%02d", week))
+		case 'F':
+			sb.WriteString(t.Format("January"))
+		case 'm':
+			sb.WriteString(t.Format("01"))
+		case 'M':
+			sb.WriteString(t.Format("Jan"))
+		case 'n':
+			sb.WriteString(strconv.Itoa(int(t.Month())))
+		case 't':
+			sb.WriteString(strconv.Itoa(time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()))
+		case 'L':
+			y := t.Year()
+			if (y%4 == 0 && y%100 != 0) || y%400 == 0 {
+				sb.WriteString("1")
+			} else {
+				sb.WriteString("0")
+			}
+		case 'o':
+			year, _ := t.ISOWeek()
+			sb.WriteString(strconv.Itoa(year))
+		case 'Y':
+			sb.WriteString(strconv.Itoa(t.Year()))
+		case 'y':
+			sb.WriteString(t.Format("06"))
+		case 'a':
+			sb.WriteString(t.Format("pm"))
+		case 'A':
+			sb.WriteString(t.Format("PM"))
+		case 'g':
+			sb.WriteString(t.Format("3"))
+		case 'G':
+			sb.WriteString(strconv.Itoa(t.Hour()))
+		case 'h':
+			sb.WriteString(t.Format("03"))
+		case 'H':
+			sb.WriteString(t.Format("15"))
+		case 'i':
+			sb.WriteString(t.Format("04"))
+		case 's':
+			sb.WriteString(t.Format("05"))
+		case 'u':
+			sb.WriteString(fmt.Sprintf("%06d", t.Nanosecond()/1000))
+		case 'v':
+			sb.WriteString(fmt.Sprintf("%03d", t.Nanosecond()/1e6))
+		case 'e':
+			sb.WriteString(t.Location().String())
+		case 'T':
+			sb.WriteString(t.Format("MST"))
+		case 'P':
+			sb.WriteString(t.Format("-07:00"))
+		case 'p':
+			if _, off := t.Zone(); off == 0 {
+				sb.WriteString("Z")
+			} else {
+				sb.WriteString(t.Format("-07:00"))
+			}
+		case 'O':
+			sb.WriteString(t.Format("-0700"))
+		case 'Z':
+			_, off := t.Zone()
+			sb.WriteString(strconv.Itoa(off))
+		case 'c':
+			sb.WriteString(t.Format("2006-01-02T15:04:05-07:00"))
+		case 'r':
+			sb.WriteString(t.Format("Mon, 02 Jan 2006 15:04:05 -0700"))
+		case 'U':
+			sb.WriteString(strconv.FormatInt(t.Unix(), 10))
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	return sb.String()
+}
+
+func ordinalSuffix(day int) string {
+	if day >= 11 && day <= 13 {
+		return "th"
+	}
+	switch day % 10 {
+	case 1:
+		return "st"
+	case 2:
+		return "nd"
+	case 3:
+		return "rd"
+	}
+	return "th"
+}
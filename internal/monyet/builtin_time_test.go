@@ -0,0 +1,69 @@
+package monyet
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDateAcceptsTimestampStrings(t *testing.T) {
+	for _, src := range []string{
+		`date("Y-m-d", "1706670000")`,
+		`date("Y-m-d", "1.70667e+09")`,
+		`date("Y-m-d", 1706670000)`,
+	} {
+		env := NewEnv()
+		env.SetVar("__TIMEZONE__", "UTC")
+		got := evalNode(NewParser(NewLexer(src)).parseExpr(), env)
+		if got != "2024-01-31" {
+			t.Errorf("%s = %v, want 2024-01-31", src, got)
+		}
+	}
+}
+
+func TestDateDiff(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		y, m, d float64
+		days    float64
+	}{
+		{"2024-01-31", "2024-03-01", 0, 0, 30, 30},
+		{"2024-01-15", "2024-03-01", 0, 1, 15, 46},
+		{"2023-12-31", "2024-02-29", 0, 1, 29, 60},
+		{"2020-02-29", "2024-02-28", 3, 11, 30, 1460},
+		{"2024-03-01", "2024-01-31", 0, 0, 30, 30},
+	}
+	for _, tt := range tests {
+		env := NewEnv()
+		env.SetVar("__TIMEZONE__", "UTC")
+		got := builtinDateDiff(env, []interface{}{tt.a, tt.b}).(map[string]interface{})
+		if got["y"] != tt.y || got["m"] != tt.m || got["d"] != tt.d || got["days"] != tt.days {
+			t.Errorf("date_diff(%s, %s) = %v", tt.a, tt.b, got)
+		}
+		for _, k := range []string{"y", "m", "d", "h", "i", "s"} {
+			if got[k].(float64) < 0 {
+				t.Errorf("date_diff(%s, %s): %s negatif", tt.a, tt.b, k)
+			}
+		}
+	}
+}
+
+func TestStrtotimeWeekdays(t *testing.T) {
+	// Rabu, 31 Januari 2024 jam 15:00
+	base := time.Date(2024, 1, 31, 15, 0, 0, 0, time.UTC)
+	tests := map[string]string{
+		"next monday":    "2024-02-05 00:00",
+		"next wednesday": "2024-02-07 00:00",
+		"last monday":    "2024-01-29 00:00",
+		"last wed":       "2024-01-24 00:00",
+		"wednesday":      "2024-01-31 00:00",
+		"friday":         "2024-02-02 00:00",
+		"next fri 10:30": "2024-02-02 10:30",
+		"next month":     "2024-03-02 15:00",
+	}
+	for in, want := range tests {
+		got, ok := parseTime(in, base, time.UTC)
+		if !ok || got.Format("2006-01-02 15:04") != want {
+			t.Errorf("strtotime(%q) = %v (ok=%v), want %s", in, got, ok, want)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+package monyet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Checker menjalankan pengecekan statis (tipe & jumlah argumen) atas sebuah
+// Program beserta file-file yang di-include, tanpa mengeksekusinya.
+type Checker struct {
+	baseDir  string
+	funcs    map[string]Function
+	included map[string]*Program
+	checked  map[string]bool
+	consts   map[string]string // nama konstanta -> tipe nilainya
+	errs     []string
+	file     string
+}
+
+// Check mengembalikan daftar masalah yang ditemukan. Slice kosong berarti lolos.
+func Check(prog *Program, file string) []string {
+	abs, _ := filepath.Abs(file)
+	c := &Checker{
+		baseDir:  filepath.Dir(abs),
+		funcs:    make(map[string]Function),
+		included: make(map[string]*Program),
+		checked:  make(map[string]bool),
+		consts:   make(map[string]string),
+		file:     filepath.Base(file),
+	}
+	for name, val := range predefinedConsts {
+		c.consts[name] = typeOf(val)
+	}
+	c.collect(prog.Statements)
+	c.checkBlock(prog.Statements, map[string]string{}, nil)
+	return c.errs
+}
+
+func (c *Checker) errorf(format string, args ...interface{}) {
+	c.errs = append(c.errs, c.file+": "+fmt.Sprintf(format, args...))
+}
+
+// collect mendaftarkan semua fungsi lebih dulu, karena fungsi boleh dipanggil
+// sebelum dideklarasikan (termasuk yang ada di file include).
+func (c *Checker) collect(stmts []Node) {
+	for _, s := range stmts {
+		switch v := s.(type) {
+		case Function:
+			c.funcs[v.Name] = v
+			c.collect(v.Body)
+		case If:
+			c.collect(v.Then)
+			c.collect(v.Else)
+		case ForeachStatement:
+			c.collect(v.Body)
+		case Include:
+			if prog := c.include(v.Path); prog != nil {
+				c.collect(prog.Statements)
+			}
+		}
+	}
+}
+
+// include mem-parse file include sekali saja. Path relatif terhadap
+// direktori script utama, sama seperti __BASE_DIR__ saat runtime.
+func (c *Checker) include(path string) (prog *Program) {
+	target := filepath.Join(c.baseDir, path)
+	if p, seen := c.included[target]; seen {
+		return p
+	}
+	c.included[target] = nil
+
+	src, err := os.ReadFile(target)
+	if err != nil {
+		c.errorf("include %q: file tidak ditemukan", path)
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			c.errs = append(c.errs, fmt.Sprintf("%s: parse error: %v", path, r))
+			prog = nil
+		}
+	}()
+	prog = NewParser(NewLexer(string(src))).Parse()
+	c.included[target] = prog
+	return prog
+}
+
+// checkBlock mengecek sederet statement. scope berisi tipe variabel yang
+// dideklarasikan, fn adalah fungsi yang sedang dicek (nil di top level).
+func (c *Checker) checkBlock(stmts []Node, scope map[string]string, fn *Function) {
+	for _, s := range stmts {
+		c.checkNode(s, scope, fn)
+	}
+}
+
+func (c *Checker) checkNode(n Node, scope map[string]string, fn *Function) {
+	switch v := n.(type) {
+	case Function:
+		c.checkFunction(v)
+	case FunctionLiteral:
+		c.checkFunction(v.Fn)
+	case DynamicCall:
+		c.checkNode(v.Callee, scope, fn)
+		for _, a := range v.Args {
+			c.checkNode(a, scope, fn)
+		}
+
+	case ConstDecl:
+		c.checkNode(v.Value, scope, fn)
+		c.defineConst(v.Name, v.Value, scope, fn)
+
+	case Assign:
+		if _, isConst := c.consts[v.Name]; isConst {
+			c.errorf("%stidak bisa mengubah konstanta %s", c.where(fn), v.Name)
+		}
+		c.checkNode(v.Value, scope, fn)
+		actual := c.exprType(v.Value, scope)
+		declared := v.Type
+		if declared == "" {
+			declared = scope[v.Name]
+		}
+		if !typeAccepts(declared, actual) {
+			c.errorf("%s$%s bertipe %s, tidak bisa diisi %s", c.where(fn), v.Name, declared, actual)
+		}
+		if v.Type != "" {
+			scope[v.Name] = v.Type
+		}
+
+	case DestructureAssign:
+		c.checkNode(v.Value, scope, fn)
+		c.checkPattern(v.Pattern, fn)
+
+	case Return:
+		c.checkNode(v.Value, scope, fn)
+		if fn == nil || fn.ReturnType == "" {
+			return
+		}
+		if fn.ReturnType == "void" {
+			if v.Value != nil {
+				c.errorf("%s() bertipe void tapi mengembalikan nilai", fn.Name)
+			}
+			return
+		}
+		if actual := c.exprType(v.Value, scope); !typeAccepts(fn.ReturnType, actual) {
+			c.errorf("%s() harus mengembalikan %s, dapat %s", fn.Name, fn.ReturnType, actual)
+		}
+
+	case Call:
+		for _, a := range v.Args {
+			c.checkNode(a, scope, fn)
+		}
+		c.checkCall(v, scope, fn)
+		if v.Name == "define" && len(v.Args) == 2 {
+			if name, ok := v.Args[0].(String); ok {
+				c.defineConst(name.Value, v.Args[1], scope, fn)
+			}
+		}
+
+	case If:
+		c.checkNode(v.Condition, scope, fn)
+		c.checkBlock(v.Then, scope, fn)
+		c.checkBlock(v.Else, scope, fn)
+
+	case ForeachStatement:
+		c.checkNode(v.Iterable, scope, fn)
+		if v.ValuePattern != nil {
+			c.checkPattern(*v.ValuePattern, fn)
+		}
+		c.checkBlock(v.Body, scope, fn)
+
+	case Include:
+		target := filepath.Join(c.baseDir, v.Path)
+		if prog := c.include(v.Path); prog != nil && !c.checked[target] {
+			c.checked[target] = true
+			prev := c.file
+			c.file = v.Path
+			c.checkBlock(prog.Statements, scope, fn)
+			c.file = prev
+		}
+
+	case Serve:
+		c.checkNode(v.Port, scope, fn)
+		for _, n := range []Node{v.Options, v.Cert, v.Key} {
+			if n != nil {
+				c.checkNode(n, scope, fn)
+			}
+		}
+		if v.Handler == "" {
+			break
+		}
+		handler, ok := c.funcs[v.Handler]
+		if !ok {
+			c.errorf("serve: handler %s tidak ditemukan", v.Handler)
+		} else if len(handler.Params) != 0 {
+			c.errorf("serve: handler %s tidak boleh punya parameter", v.Handler)
+		}
+
+	case Yield:
+		c.checkNode(v.Key, scope, fn)
+		c.checkNode(v.Value, scope, fn)
+	case Echo:
+		c.checkNode(v.Value, scope, fn)
+	case Binary:
+		c.checkNode(v.Left, scope, fn)
+		c.checkNode(v.Right, scope, fn)
+	case IndexAccess:
+		c.checkNode(v.Left, scope, fn)
+		c.checkNode(v.Index, scope, fn)
+	case IndexAssign:
+		c.checkNode(v.Left, scope, fn)
+		c.checkNode(v.Value, scope, fn)
+	case MapLiteral:
+		for k, val := range v.Pairs {
+			c.checkNode(k, scope, fn)
+			c.checkNode(val, scope, fn)
+		}
+	}
+}
+
+func (c *Checker) checkFunction(f Function) {
+	local := map[string]string{}
+	for i, p := range f.Params {
+		if i < len(f.ParamTypes) && f.ParamTypes[i] != "" {
+			local[p] = f.ParamTypes[i]
+		}
+	}
+	if f.Name == "" {
+		f.Name = "function"
+	}
+	c.checkBlock(f.Body, local, &f)
+}
+
+func (c *Checker) checkCall(v Call, scope map[string]string, fn *Function) {
+	_, isUser := c.funcs[v.Name]
+	if b, ok := LookupBuiltin(v.Name); ok && !isUser {
+		if !b.acceptsArgs(len(v.Args)) {
+			c.errorf("%s%s() butuh %s argumen, dapat %d", c.where(fn), v.Name, b.arityString(), len(v.Args))
+			return
+		}
+		// route("GET", "/x", "nama_handler") harus menunjuk function yang ada
+		if v.Name == "route" {
+			if name, ok := v.Args[2].(String); ok {
+				if _, exists := c.funcs[name.Value]; !exists {
+					c.errorf("%sroute: handler %s tidak ditemukan", c.where(fn), name.Value)
+				}
+			}
+		}
+		// use("auth") harus function yang ada atau middleware Go bawaan
+		if v.Name == "use" || v.Name == "middleware" {
+			if name, ok := v.Args[0].(String); ok {
+				_, isFunc := c.funcs[name.Value]
+				if _, isGo := goMiddlewares[name.Value]; !isFunc && !isGo {
+					c.errorf("%s%s: middleware %s tidak ditemukan", c.where(fn), v.Name, name.Value)
+				}
+			}
+		}
+		for i, a := range v.Args {
+			if _, isVar := a.(Variable); b.isRef(i) && !isVar {
+				c.errorf("%sargumen ke-%d %s() harus berupa variabel", c.where(fn), i+1, v.Name)
+				continue
+			}
+			actual := c.exprType(a, scope)
+			if i < len(b.Params) && !typeAccepts(b.Params[i], actual) {
+				c.errorf("%sargumen ke-%d %s() harus %s, dapat %s", c.where(fn), i+1, v.Name, b.Params[i], actual)
+			}
+		}
+		return
+	}
+
+	target, ok := c.funcs[v.Name]
+	if !ok {
+		c.errorf("%sfungsi %s() tidak didefinisikan", c.where(fn), v.Name)
+		return
+	}
+	if len(v.Args) != len(target.Params) {
+		c.errorf("%s%s() butuh %d argumen, dapat %d", c.where(fn), v.Name, len(target.Params), len(v.Args))
+		return
+	}
+	for i, a := range v.Args {
+		if i >= len(target.ParamTypes) {
+			break
+		}
+		if actual := c.exprType(a, scope); !typeAccepts(target.ParamTypes[i], actual) {
+			c.errorf("%sargumen $%s di %s() harus %s, dapat %s", c.where(fn), target.Params[i], v.Name, target.ParamTypes[i], actual)
+		}
+	}
+}
+
+// checkPattern memastikan destructuring tidak menimpa konstanta.
+func (c *Checker) checkPattern(pat ListPattern, fn *Function) {
+	for _, item := range pat.Items {
+		switch t := item.Target.(type) {
+		case Variable:
+			if _, isConst := c.consts[t.Name]; isConst {
+				c.errorf("%stidak bisa mengubah konstanta %s", c.where(fn), t.Name)
+			}
+		case ListPattern:
+			c.checkPattern(t, fn)
+		}
+	}
+}
+
+func (c *Checker) defineConst(name string, val Node, scope map[string]string, fn *Function) {
+	if _, exists := c.consts[name]; exists {
+		c.errorf("%skonstanta %s sudah didefinisikan", c.where(fn), name)
+		return
+	}
+	c.consts[name] = c.exprType(val, scope)
+}
+
+func (c *Checker) where(fn *Function) string {
+	if fn == nil {
+		return ""
+	}
+	return "di " + fn.Name + "(): "
+}
+
+// exprType menebak tipe ekspresi secara statis. "" berarti tidak diketahui,
+// dan tipe yang tidak diketahui selalu dianggap cocok.
+func (c *Checker) exprType(n Node, scope map[string]string) string {
+	switch v := n.(type) {
+	case Number:
+		return typeOf(v.Value)
+	case String:
+		return "string"
+	case MapLiteral:
+		return "array"
+	case FunctionLiteral:
+		return "callable"
+	case Variable:
+		if t, ok := scope[v.Name]; ok {
+			return t
+		}
+		return c.consts[v.Name]
+	case Identifier:
+		if t, ok := scope[v.Name]; ok {
+			return t
+		}
+		return c.consts[v.Name]
+	case Call:
+		if _, isUser := c.funcs[v.Name]; !isUser {
+			if b, ok := LookupBuiltin(v.Name); ok {
+				return b.Returns
+			}
+		}
+		if target, ok := c.funcs[v.Name]; ok && target.ReturnType != "mixed" && target.ReturnType != "void" {
+			return target.ReturnType
+		}
+	case Binary:
+		switch v.Op {
+		case EQ, GT, AND, OR:
+			return "bool"
+		}
+		l, r := c.exprType(v.Left, scope), c.exprType(v.Right, scope)
+		if v.Op == PLUS && (l == "string" || r == "string") {
+			return "string"
+		}
+		if v.Op == SLASH || l == "" || r == "" {
+			return ""
+		}
+		if l == "int" && r == "int" {
+			return "int"
+		}
+		if typeAccepts("float", l) && typeAccepts("float", r) {
+			return "float"
+		}
+	}
+	return ""
+}
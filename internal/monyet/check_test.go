@@ -0,0 +1,110 @@
+package monyet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// checkSource mem-parse dan mengecek src seperti `monyet check`. Parse
+// error (misalnya tipe yang tidak dikenal) dikembalikan sebagai satu error.
+func checkSource(file, src string) (errs []string) {
+	defer func() {
+		if r := recover(); r != nil {
+			errs = []string{fmt.Sprintf("parse error: %v", r)}
+		}
+	}()
+	return Check(NewParser(NewLexer(src)).Parse(), file)
+}
+
+func TestCheckAcceptsValidScripts(t *testing.T) {
+	for _, src := range []string{
+		`function add(int $a, int $b): int { return $a + $b; }
+$n = add(1, 2);
+int $total = add($n, 3);`,
+		`function half(float $x): float { return $x / 2; }
+$h = half(3);`,
+		`function greet(string $name): string { return "halo " + $name; }
+echo strlen(greet("budi"));`,
+		`function each_item(array $items, callable $fn): void { array_map($fn, $items); }
+each_item([1, 2], fn($x) => $x);
+each_item([1], "strlen");`,
+		`function nothing(): void { return; }`,
+		`function anything(mixed $x): mixed { return $x; }
+$s = strlen(anything(1));`,
+		`const LIMIT = 10;
+int $n = LIMIT;`,
+		`$x = later(); function later(): int { return 1; }`,
+	} {
+		if errs := checkSource("app.nyet", src); len(errs) > 0 {
+			t.Errorf("script valid ditolak:\n%s\nerrs = %q", src, errs)
+		}
+	}
+}
+
+func TestCheckReportsErrors(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		// argumen builtin bertipe salah
+		{`array_keys("bukan array");`, "argumen ke-1 array_keys() harus array, dapat string"},
+		{`$a = array_map(5, [1]);`, "argumen ke-1 array_map() harus callable, dapat int"},
+		{`function f(int $n) { return file_get_contents($n); }`, "di f(): argumen ke-1 file_get_contents() harus string, dapat int"},
+		{`in_array(1, [1], "ya");`, "argumen ke-3 in_array() harus bool, dapat string"},
+		{`strlen("a", "b");`, "strlen() butuh 1 argumen, dapat 2"},
+		{`preg_match("/a/", "a", "bukan variabel");`, "argumen ke-3 preg_match() harus berupa variabel"},
+		// argumen function user
+		{`function sq(int $x): int { return $x * $x; } sq("a");`, "argumen $x di sq() harus int, dapat string"},
+		{`function sq(int $x): int { return $x * $x; } sq(1, 2);`, "sq() butuh 1 argumen, dapat 2"},
+		{`tidak_ada(1);`, "fungsi tidak_ada() tidak didefinisikan"},
+		// tipe return salah
+		{`function name(): string { return 42; }`, "name() harus mengembalikan string, dapat int"},
+		{`function ratio(): int { return 1.5; }`, "ratio() harus mengembalikan int, dapat float"},
+		{`function ok(): bool { return strlen("x"); }`, "ok() harus mengembalikan bool, dapat int"},
+		{`function v(): void { return 1; }`, "v() bertipe void tapi mengembalikan nilai"},
+		// variabel bertipe dan konstanta
+		{`int $n = "a";`, "$n bertipe int, tidak bisa diisi string"},
+		{`int $n = 1; $n = "a";`, "$n bertipe int, tidak bisa diisi string"},
+		{`const A = 1; A = 2;`, "tidak bisa mengubah konstanta A"},
+		{`const A = 1; const A = 2;`, "konstanta A sudah didefinisikan"},
+		// nama tipe yang tidak dikenal
+		{`function f(integer $x) { return $x; }`, "Unknown type: integer"},
+		{`function f(): str { return "a"; }`, "Unknown type: str"},
+		{`function f(void $x) { return $x; }`, "Unknown type: void"},
+		// route dan middleware
+		{`route("GET", "/", "tidak_ada");`, "route: handler tidak_ada tidak ditemukan"},
+		{`use("tidak_ada");`, "use: middleware tidak_ada tidak ditemukan"},
+	}
+	for _, tt := range tests {
+		errs := checkSource("app.nyet", tt.src)
+		if len(errs) == 0 || !strings.Contains(strings.Join(errs, "\n"), tt.want) {
+			t.Errorf("%s\nerrs = %q, want %q", tt.src, errs, tt.want)
+		}
+	}
+}
+
+func TestCheckIncludes(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "lib.nyet"), []byte(`function sq(int $x): int { return $x * $x; }
+function bad(): string { return 1; }`), 0644)
+	os.WriteFile(filepath.Join(dir, "rusak.nyet"), []byte(`function f(angka $x) { return $x; }`), 0644)
+
+	main := filepath.Join(dir, "app.nyet")
+	errs := checkSource(main, `include "lib.nyet";
+$n = sq("a");`)
+	want := []string{
+		`lib.nyet: bad() harus mengembalikan string, dapat int`,
+		`app.nyet: argumen $x di sq() harus int, dapat string`,
+	}
+	if strings.Join(errs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("errs = %q, want %q", errs, want)
+	}
+
+	errs = checkSource(main, `include "rusak.nyet"; include "hilang.nyet";`)
+	joined := strings.Join(errs, "\n")
+	if !strings.Contains(joined, "rusak.nyet: parse error: Unknown type: angka") || !strings.Contains(joined, `include "hilang.nyet": file tidak ditemukan`) {
+		t.Errorf("errs = %q", errs)
+	}
+}
@@ -0,0 +1,223 @@
+package monyet
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Config menggabungkan beberapa sumber konfigurasi. Urutan prioritas dari
+// yang paling kuat:
+//
+//  1. override dari CLI (monyet run --set port=9090)
+//  2. environment variable asli (MONYET_PORT lebih kuat dari PORT)
+//  3. file .env di samping script
+//  4. file config.json di samping script
+//
+// Key dinormalisasi: huruf kecil, "." dan "-" menjadi "_", jadi
+// config("db.name"), DB_NAME dan {"db": {"name": ..}} menunjuk key yang sama.
+//
+// Setting runtime (port, db_path, server.* dan seterusnya) tidak diambil
+// dari environment variable tanpa awalan: PORT dari platform tidak boleh
+// diam-diam mengganti serve(8080). Pakai MONYET_PORT, .env atau --set.
+type Config struct {
+	values   map[string]interface{}
+	sources  map[string]string
+	settings map[string]interface{} // seperti values, tanpa env var tanpa awalan
+}
+
+// LoadConfig membaca config.json dan .env dari dir lalu menerapkan
+// environment variable dan override "key=value". Nilai dari .env juga
+// dimasukkan ke environment proses (kalau belum ada) supaya getenv() ikut
+// melihatnya.
+func LoadConfig(dir string, overrides []string) (*Config, error) {
+	c := &Config{values: map[string]interface{}{}, sources: map[string]string{}, settings: map[string]interface{}{}}
+
+	if raw, err := os.ReadFile(filepath.Join(dir, "config.json")); err == nil {
+		var data map[string]interface{}
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return nil, fmt.Errorf("config.json: %v", err)
+		}
+		c.flatten("", data, "config.json")
+	}
+
+	dotenv, err := parseDotenv(filepath.Join(dir, ".env"))
+	if err != nil {
+		return nil, err
+	}
+	for _, kv := range dotenv {
+		c.set(strings.TrimPrefix(kv[0], "MONYET_"), kv[1], ".env")
+		if _, exists := os.LookupEnv(kv[0]); !exists {
+			os.Setenv(kv[0], kv[1])
+		}
+	}
+
+	// Environment asli, tapi lewati yang barusan diisi dari .env
+	fromDotenv := map[string]bool{}
+	for _, kv := range dotenv {
+		fromDotenv[kv[0]] = os.Getenv(kv[0]) == kv[1]
+	}
+	var prefixed [][2]string
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		if fromDotenv[k] {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(k, "MONYET_"); ok && rest != "" {
+			prefixed = append(prefixed, [2]string{rest, v})
+			continue
+		}
+		// Hanya terlihat lewat config()/getenv(), bukan sebagai setting runtime
+		nk := normalizeConfigKey(k)
+		c.values[nk], c.sources[nk] = v, "env"
+	}
+	for _, kv := range prefixed {
+		c.set(kv[0], kv[1], "env")
+	}
+
+	for _, o := range overrides {
+		k, v, ok := strings.Cut(o, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("--set %q: format harus key=value", o)
+		}
+		c.set(strings.TrimSpace(k), v, "cli")
+	}
+	return c, nil
+}
+
+func normalizeConfigKey(key string) string {
+	return strings.NewReplacer(".", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(key)))
+}
+
+func (c *Config) set(key string, val interface{}, source string) {
+	k := normalizeConfigKey(key)
+	c.values[k] = val
+	c.sources[k] = source
+	c.settings[k] = val
+}
+
+// flatten meratakan object JSON bersarang: {"db": {"name": "x"}} -> db_name.
+func (c *Config) flatten(prefix string, data map[string]interface{}, source string) {
+	for k, v := range data {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if m, ok := v.(map[string]interface{}); ok {
+			c.flatten(key, m, source)
+			continue
+		}
+		c.set(key, v, source)
+	}
+}
+
+// Get mengembalikan nilai config beserta ada/tidaknya.
+func (c *Config) Get(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	v, ok := c.values[normalizeConfigKey(key)]
+	return v, ok
+}
+
+// Setting seperti Get, tapi untuk setting yang dipakai runtime: nilai dari
+// environment variable tanpa awalan MONYET_ diabaikan.
+func (c *Config) Setting(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	v, ok := c.settings[normalizeConfigKey(key)]
+	return v, ok
+}
+
+// Source mengembalikan asal sebuah key ("cli", "env", ".env", "config.json").
+func (c *Config) Source(key string) string {
+	if c == nil {
+		return ""
+	}
+	return c.sources[normalizeConfigKey(key)]
+}
+
+// Keys mengembalikan semua key yang sudah dinormalisasi, terurut.
+func (c *Config) Keys() []string {
+	if c == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(c.values))
+	for k := range c.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SetConfig memasang config untuk script. Disimpan di scope paling luar
+// seperti __BASE_DIR__.
+func (e *Env) SetConfig(c *Config) {
+	e.root().SetVar("__CONFIG__", c)
+}
+
+func scriptConfig(env *Env) *Config {
+	v, _ := env.GetVar("__CONFIG__")
+	c, _ := v.(*Config)
+	return c
+}
+
+// configString mengambil setting runtime sebagai string, "" kalau tidak ada.
+func configString(env *Env, key string) string {
+	if v, ok := scriptConfig(env).Setting(key); ok && v != nil {
+		return argString(v)
+	}
+	return ""
+}
+
+// parseDotenv membaca file .env: KEY=value per baris, komentar #, awalan
+// "export", nilai dengan kutip ganda (mendukung \n) atau kutip tunggal
+// (apa adanya). File yang tidak ada bukan error.
+func parseDotenv(path string) ([][2]string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out [][2]string
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		k, v, ok := strings.Cut(line, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("%s:%d: format harus KEY=value", path, lineNo)
+		}
+		v = strings.TrimSpace(v)
+
+		switch {
+		case len(v) >= 2 && v[0] == '"' && strings.LastIndexByte(v, '"') > 0:
+			v = v[1:strings.LastIndexByte(v, '"')]
+			v = strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`).Replace(v)
+		case len(v) >= 2 && v[0] == '\'' && strings.LastIndexByte(v, '\'') > 0:
+			v = v[1:strings.LastIndexByte(v, '\'')]
+		default:
+			// Komentar di belakang nilai tanpa kutip: PORT=8080 # default
+			if i := strings.Index(v, " #"); i >= 0 {
+				v = strings.TrimSpace(v[:i])
+			}
+		}
+		out = append(out, [2]string{k, v})
+	}
+	return out, scanner.Err()
+}
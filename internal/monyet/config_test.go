@@ -0,0 +1,50 @@
+package monyet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRuntimeSettingsIgnoreGenericEnv(t *testing.T) {
+	t.Setenv("PORT", "5000")
+	t.Setenv("MONYET_DB_PATH", "prod.db")
+
+	cfg, err := LoadConfig(t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := cfg.Setting("port"); ok {
+		t.Errorf("PORT dari environment tidak boleh jadi setting, dapat %v", v)
+	}
+	if v, _ := cfg.Get("port"); v != "5000" {
+		t.Errorf(`config("port") = %v, want 5000`, v)
+	}
+	if v, _ := cfg.Setting("db_path"); v != "prod.db" {
+		t.Errorf("MONYET_DB_PATH harus dipakai, dapat %v", v)
+	}
+}
+
+func TestRuntimeSettingsFromDotenvAndCLI(t *testing.T) {
+	t.Setenv("PORT", "5000")
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("PORT=9090\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := cfg.Setting("port"); v != "9090" {
+		t.Errorf("port dari .env = %v, want 9090", v)
+	}
+
+	cfg, err = LoadConfig(dir, []string{"port=7070"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := cfg.Setting("port"); v != "7070" {
+		t.Errorf("port dari --set = %v, want 7070", v)
+	}
+}
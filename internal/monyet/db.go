@@ -93,6 +93,14 @@ func (db *MonyetDB) Get(key string) string {
 	return ""
 }
 
+// Has melaporkan apakah key pernah ditulis, termasuk yang sudah dihapus.
+func (db *MonyetDB) Has(key string) bool {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	_, ok := db.index[key]
+	return ok
+}
+
 // Tambahkan fungsi ini di db.go
 func (db *MonyetDB) Delete(key string) error {
 	db.mu.Lock()
@@ -113,6 +121,64 @@ func (db *MonyetDB) Delete(key string) error {
 	return err
 }
 
+// Cursor membaca log dari awal dan hanya mengembalikan baris yang masih
+// menjadi versi terakhir di index, jadi seluruh isi DB tidak pernah dimuat
+// sekaligus ke memori.
+type Cursor struct {
+	db      *MonyetDB
+	f       *os.File
+	scanner *bufio.Scanner
+	offset  int64
+	prefix  string
+}
+
+func (db *MonyetDB) Cursor(prefix string) *Cursor {
+	return &Cursor{db: db, prefix: prefix}
+}
+
+func (c *Cursor) Next() (key, value string, ok bool) {
+	if c.f == nil {
+		f, err := os.Open(c.db.path)
+		if err != nil {
+			return "", "", false
+		}
+		c.f = f
+		c.scanner = bufio.NewScanner(f)
+	}
+
+	for c.scanner.Scan() {
+		line := c.scanner.Text()
+		offset := c.offset
+		c.offset += int64(len(line) + 1)
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || parts[1] == "__DELETED__" || !strings.HasPrefix(parts[0], c.prefix) {
+			continue
+		}
+		c.db.mu.RLock()
+		latest, exists := c.db.index[parts[0]]
+		c.db.mu.RUnlock()
+		if exists && latest == offset {
+			return parts[0], parts[1], true
+		}
+	}
+	return "", "", false
+}
+
+func (c *Cursor) Close() {
+	if c.f != nil {
+		c.f.Close()
+	}
+}
+
+// Close menutup file database. Tulisan yang sedang berjalan ditunggu dulu
+// lewat lock.
+func (db *MonyetDB) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.file.Close()
+}
+
 func (db *MonyetDB) Drop() error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
@@ -1,45 +1,175 @@
 package monyet
 
 type Env struct {
-	vars  map[string]interface{}
-	funcs map[string]Function
-	outer *Env
+	vars   map[string]interface{}
+	types  map[string]string // tipe yang dideklarasikan: int $a = 1;
+	funcs  map[string]Function
+	consts map[string]interface{}
+	gen    *genState       // tidak nil kalau sedang di dalam body generator
+	req    *requestContext // tidak nil kalau sedang melayani request HTTP
+	outer  *Env
+}
+
+// Konstanta bawaan yang selalu tersedia di setiap script.
+var predefinedConsts = map[string]interface{}{
+	"true":    true,
+	"false":   false,
+	"null":    nil,
+	"PHP_EOL": "\n",
 }
 
 func NewEnv() *Env {
-	return &Env{
-		vars:  make(map[string]interface{}),
-		funcs: make(map[string]Function),
+	env := &Env{
+		vars:   make(map[string]interface{}),
+		types:  make(map[string]string),
+		funcs:  make(map[string]Function),
+		consts: make(map[string]interface{}),
+	}
+	for name, val := range predefinedConsts {
+		env.consts[name] = val
 	}
+	return env
 }
 
 func NewChildEnv(outer *Env) *Env {
 	return &Env{
-		vars:  make(map[string]interface{}),
-		funcs: outer.funcs, // share functions
-		outer: outer,
+		vars:   make(map[string]interface{}),
+		types:  make(map[string]string),
+		funcs:  outer.funcs,  // share functions
+		consts: outer.consts, // konstanta juga global
+		gen:    outer.gen,
+		req:    outer.req,
+		outer:  outer,
 	}
 }
 
+// GetVar mencari variabel dari scope terdalam ke luar. Kalau tidak ada,
+// baru dicari di konstanta.
 func (e *Env) GetVar(name string) (interface{}, bool) {
-	if v, ok := e.vars[name]; ok {
+	if v, ok := e.lookupVar(name); ok {
 		return v, true
 	}
-	if e.outer != nil {
-		return e.outer.GetVar(name)
+	if e.req != nil {
+		return e.req.constant(name, e.consts)
+	}
+	v, ok := e.consts[name]
+	return v, ok
+}
+
+// Isolasi request: saat serve berjalan, banyak request membaca scope yang
+// sama (scope global dan scope tempat closure dibuat). Scope itu tidak
+// pernah ditulis oleh request. Penulisan masuk ke overlay milik request,
+// dan array dari scope bersama disalin saat pertama dibaca, jadi setiap
+// request punya salinan global sendiri seperti di PHP. Data yang memang
+// harus dibagi antar request disimpan lewat shared_set() dan kawan-kawan.
+
+// isShared: scope cur dibuat di luar request yang sedang berjalan.
+func (e *Env) isShared(cur *Env) bool {
+	return e.req != nil && cur.req != e.req
+}
+
+// root mengembalikan scope paling luar (scope script utama).
+func (e *Env) root() *Env {
+	for e.outer != nil {
+		e = e.outer
+	}
+	return e
+}
+
+// lookupVar seperti GetVar tapi tanpa melihat konstanta.
+func (e *Env) lookupVar(name string) (interface{}, bool) {
+	for cur := e; cur != nil; cur = cur.outer {
+		if e.isShared(cur) {
+			if v, ok := e.req.sharedVar(cur, name); ok {
+				return v, true
+			}
+			continue
+		}
+		if v, ok := cur.vars[name]; ok {
+			return v, true
+		}
 	}
 	return nil, false
 }
 
+// scopeOf mengembalikan scope terdekat yang punya variabel name, atau nil.
+func (e *Env) scopeOf(name string) *Env {
+	for cur := e; cur != nil; cur = cur.outer {
+		if e.isShared(cur) {
+			if _, ok := e.req.sharedVar(cur, name); ok {
+				return cur
+			}
+			continue
+		}
+		if _, ok := cur.vars[name]; ok {
+			return cur
+		}
+	}
+	return nil
+}
+
 func (e *Env) SetVar(name string, val interface{}) {
 	e.vars[name] = val
 }
 
+// setVarIn menulis variabel ke scope target, atau ke overlay request kalau
+// target adalah scope bersama.
+func (e *Env) setVarIn(target *Env, name string, val interface{}) {
+	if e.isShared(target) {
+		e.req.setSharedVar(target, name, val)
+		return
+	}
+	target.SetVar(name, val)
+}
+
+// GetType hanya melihat scope saat ini, karena assignment selalu
+// menulis ke scope saat ini juga.
+func (e *Env) GetType(name string) string {
+	return e.types[name]
+}
+
+func (e *Env) SetType(name, typ string) {
+	e.types[name] = typ
+}
+
+func (e *Env) IsConst(name string) bool {
+	if e.req != nil {
+		if _, ok := e.req.consts[name]; ok {
+			return true
+		}
+	}
+	_, ok := e.consts[name]
+	return ok
+}
+
+// DefineConst mendaftarkan konstanta baru. Konstanta tidak bisa didefinisikan ulang.
+func (e *Env) DefineConst(name string, val interface{}) {
+	if e.IsConst(name) {
+		panic("konstanta " + name + " sudah didefinisikan")
+	}
+	if e.req != nil {
+		e.req.defineConst(name, val)
+		return
+	}
+	e.consts[name] = val
+}
+
 func (e *Env) GetFunc(name string) (Function, bool) {
+	if e.req != nil {
+		if fn, ok := e.req.funcs[name]; ok {
+			return fn, true
+		}
+	}
 	fn, ok := e.funcs[name]
 	return fn, ok
 }
 
+// SetFunc mendaftarkan function. Function yang dideklarasikan saat
+// melayani request hanya berlaku untuk request itu.
 func (e *Env) SetFunc(name string, fn Function) {
+	if e.req != nil {
+		e.req.defineFunc(name, fn)
+		return
+	}
 	e.funcs[name] = fn
 }
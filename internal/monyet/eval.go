@@ -1,17 +1,12 @@
 package monyet
 
 import (
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
-var activeStorage *MonyetDB
-var activeDBPath string
-
 func Eval(prog *Program, env *Env) {
 	for _, s := range prog.Statements {
 		evalNode(s, env)
@@ -22,6 +17,201 @@ type returnValue struct {
 	value interface{}
 }
 
+// assignVar menulis variabel di scope saat ini dengan tetap menghormati
+// konstanta dan anotasi tipe yang sudah dideklarasikan.
+func assignVar(env *Env, name string, val interface{}) {
+	if env.IsConst(name) {
+		panic("tidak bisa mengubah konstanta " + name)
+	}
+	if t := env.GetType(name); t != "" {
+		checkType(t, val, "$"+name)
+	}
+	env.SetVar(name, val)
+}
+
+// assignExisting menulis ke scope terdekat yang sudah punya variabel itu.
+// Kalau belum ada di mana pun, variabel dibuat di scope saat ini.
+func assignExisting(env *Env, name string, val interface{}) {
+	cur := env.scopeOf(name)
+	if cur == nil {
+		assignVar(env, name, val)
+		return
+	}
+	if env.IsConst(name) {
+		panic("tidak bisa mengubah konstanta " + name)
+	}
+	if t := cur.GetType(name); t != "" {
+		checkType(t, val, "$"+name)
+	}
+	env.setVarIn(cur, name, val)
+}
+
+// lookupName mencari variabel atau konstanta. Field form gaya lama
+// ($GET_nama dst.) yang tidak dikirim bernilai "".
+func lookupName(env *Env, name string) (interface{}, bool) {
+	if val, ok := env.GetVar(name); ok {
+		return val, true
+	}
+	for _, p := range []string{"GET_", "POST_", "PATCH_", "DELETE_"} {
+		if strings.HasPrefix(name, p) {
+			return "", true
+		}
+	}
+	return nil, false
+}
+
+// destructure membongkar array/map ke variabel sesuai pola. Key yang tidak
+// ada menghasilkan nil, sama seperti akses index biasa.
+func destructure(pat ListPattern, val interface{}, env *Env) {
+	for _, item := range pat.Items {
+		elem := indexValue(val, evalNode(item.Key, env))
+		switch t := item.Target.(type) {
+		case Variable:
+			assignVar(env, t.Name, elem)
+		case ListPattern:
+			destructure(t, elem, env)
+		}
+	}
+}
+
+// indexValue mengambil elemen dari map atau slice. Angka di MonyetLang
+// selalu float64, jadi index slice dikonversi dulu ke int.
+func indexValue(container, index interface{}) interface{} {
+	switch c := container.(type) {
+	case map[string]interface{}:
+		return c[formatValue(index)]
+	case []interface{}:
+		var i int
+		switch idx := index.(type) {
+		case float64:
+			i = int(idx)
+		case int:
+			i = idx
+		default:
+			return nil
+		}
+		if i >= 0 && i < len(c) {
+			return c[i]
+		}
+	}
+	return nil
+}
+
+// Closure adalah function anonim beserta Env tempat ia dibuat, jadi
+// variabel di sekitarnya tetap bisa diakses saat dipanggil nanti.
+type Closure struct {
+	Fn  Function
+	Env *Env
+}
+
+// NativeFunc adalah callable yang dibuat dari Go, misalnya $next di
+// middleware. Dipanggil dari script seperti closure biasa: $next().
+type NativeFunc struct {
+	Name string
+	Fn   func(args []interface{}) interface{}
+}
+
+func (f *NativeFunc) String() string {
+	return "Closure(" + f.Name + ")"
+}
+
+// callValue memanggil nilai callable: closure, nama function user, atau
+// nama fungsi bawaan.
+func callValue(callee interface{}, args []interface{}, env *Env) interface{} {
+	switch c := callee.(type) {
+	case *Closure:
+		// Closure yang dibuat di luar request tetap membawa request yang
+		// sedang berjalan, supaya header() dkk. bisa dipakai di dalamnya
+		if env.req != nil && c.Env.req != env.req {
+			scope := NewChildEnv(c.Env)
+			scope.req = env.req
+			return callFunction(c.Fn, args, scope)
+		}
+		return callFunction(c.Fn, args, c.Env)
+	case *NativeFunc:
+		return c.Fn(args)
+	case string:
+		if fn, ok := env.GetFunc(c); ok {
+			return callFunction(fn, args, env)
+		}
+		if b, ok := LookupBuiltin(c); ok {
+			return b.call(env, args)
+		}
+		panic("undefined function: " + c)
+	}
+	panic(fmt.Sprintf("nilai %v (%s) tidak bisa dipanggil", callee, typeOf(callee)))
+}
+
+// isTruthy mengikuti aturan PHP: false, 0, "", "0", null dan array kosong
+// dianggap false.
+func isTruthy(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case float64:
+		return t != 0
+	case int:
+		return t != 0
+	case string:
+		return t != "" && t != "0"
+	case map[string]interface{}:
+		return len(t) > 0
+	case []interface{}:
+		return len(t) > 0
+	}
+	return true
+}
+
+// callFunction menjalankan fungsi user dengan argumen yang sudah dievaluasi.
+// Jumlah argumen dan anotasi tipe (kalau ada) dicek di sini.
+func callFunction(fn Function, args []interface{}, env *Env) interface{} {
+	if len(args) != len(fn.Params) {
+		panic(fmt.Sprintf("function %s expects %d arguments, got %d", fn.Name, len(fn.Params), len(args)))
+	}
+
+	local := NewChildEnv(env)
+	for i, p := range fn.Params {
+		if i < len(fn.ParamTypes) && fn.ParamTypes[i] != "" {
+			t := fn.ParamTypes[i]
+			checkType(t, args[i], fmt.Sprintf("argumen $%s di %s()", p, fn.Name))
+			local.SetType(p, t)
+		}
+		local.SetVar(p, args[i])
+	}
+
+	// Function yang berisi yield tidak langsung dijalankan, tapi
+	// mengembalikan generator yang baru jalan saat di-foreach.
+	if fn.Generator {
+		gen := newFuncGenerator(fn.Body, local)
+		if fn.ReturnType != "" {
+			checkType(fn.ReturnType, gen, fmt.Sprintf("nilai return %s()", fn.Name))
+		}
+		return gen
+	}
+
+	var result interface{}
+	for _, stmt := range fn.Body {
+		val := evalNode(stmt, local)
+		if rv, ok := val.(returnValue); ok {
+			result = rv.value
+			break
+		}
+	}
+
+	switch fn.ReturnType {
+	case "":
+	case "void":
+		if result != nil {
+			panic(fmt.Sprintf("type error: %s() bertipe void tapi mengembalikan %s", fn.Name, typeOf(result)))
+		}
+	default:
+		checkType(fn.ReturnType, result, fmt.Sprintf("nilai return %s()", fn.Name))
+	}
+	return result
+}
+
 func evalNode(n Node, env *Env) interface{} {
 	switch v := n.(type) {
 
@@ -32,34 +222,51 @@ func evalNode(n Node, env *Env) interface{} {
 		return v.Value
 
 	case Variable:
-		val, ok := env.GetVar(v.Name)
-		if !ok {
-			prefixes := []string{"GET_", "POST_", "PATCH_", "DELETE_"}
-			for _, p := range prefixes {
-				if strings.HasPrefix(v.Name, p) {
-					return ""
-				}
-			}
-			panic("undefined variable: $" + v.Name)
+		if val, ok := lookupName(env, v.Name); ok {
+			return val
 		}
-		return val
+		panic("undefined variable: $" + v.Name)
+
+	case Identifier:
+		if val, ok := lookupName(env, v.Name); ok {
+			return val
+		}
+		// Nama function tanpa $ dipakai sebagai callable: array_map(double, $a).
+		// Hanya berlaku untuk nama tanpa $, jadi $typo tetap error.
+		if _, isFunc := env.GetFunc(v.Name); isFunc {
+			return v.Name
+		}
+		if _, isBuiltin := LookupBuiltin(v.Name); isBuiltin {
+			return v.Name
+		}
+		panic("undefined constant: " + v.Name)
 
 	case Assign:
+		if env.IsConst(v.Name) {
+			panic("tidak bisa mengubah konstanta " + v.Name)
+		}
+		val := evalNode(v.Value, env)
+		if v.Type != "" {
+			checkType(v.Type, val, "$"+v.Name)
+			env.SetType(v.Name, v.Type)
+		}
+		assignVar(env, v.Name, val)
+		return val
+
+	case DestructureAssign:
 		val := evalNode(v.Value, env)
-		env.SetVar(v.Name, val)
+		destructure(v.Pattern, val, env)
 		return val
 
 	case Binary:
+		// && dan || memakai aturan truthy yang sama dengan if, dan berhenti
+		// begitu hasilnya pasti: $user && $user["admin"] aman kalau $user null
 		if v.Op == AND || v.Op == OR {
-			lVal, okL := evalNode(v.Left, env).(bool)
-			rVal, okR := evalNode(v.Right, env).(bool)
-			if !okL || !okR {
-				panic("Operasi logika && atau || membutuhkan tipe boolean")
+			l := isTruthy(evalNode(v.Left, env))
+			if v.Op == AND && !l || v.Op == OR && l {
+				return l
 			}
-			if v.Op == AND {
-				return lVal && rVal
-			}
-			return lVal || rVal
+			return isTruthy(evalNode(v.Right, env))
 		}
 
 		// 2. Evaluasi Nilai Kiri dan Kanan untuk operasi lainnya
@@ -81,7 +288,7 @@ func evalNode(n Node, env *Env) interface{} {
 			_, lok := left.(string)
 			_, rok := right.(string)
 			if lok || rok {
-				return fmt.Sprintf("%v%v", left, right)
+				return formatValue(left) + formatValue(right)
 			}
 		}
 
@@ -108,9 +315,17 @@ func evalNode(n Node, env *Env) interface{} {
 			return li > ri
 		}
 
+	case ConstDecl:
+		env.DefineConst(v.Name, evalNode(v.Value, env))
+		return nil
+
 	case Echo:
 		val := evalNode(v.Value, env)
-		fmt.Println(val)
+		if n, ok := val.(float64); ok {
+			fmt.Println(formatValue(n))
+		} else {
+			fmt.Println(val)
+		}
 		return nil
 
 	case Function:
@@ -118,104 +333,57 @@ func evalNode(n Node, env *Env) interface{} {
 		return nil
 
 	case Call:
-		getStorage := func() *MonyetDB {
-			base, _ := env.GetVar("__BASE_DIR__")
-			dbPath := filepath.Join(base.(string), "monyet.db")
-
-			if customName, ok := env.GetVar("DB_NAME"); ok {
-				dbPath = filepath.Join(base.(string), fmt.Sprintf("%v", customName))
-			}
-
-			// Jika path-nya masih sama dan storage sudah ada, pakai yang lama saja
-			if activeStorage != nil && activeDBPath == dbPath {
-				return activeStorage
-			}
-
-			// Jika ganti nama DB atau baru pertama kali, buka koneksi baru
-			activeDBPath = dbPath
-			activeStorage = NewMonyetDB(dbPath)
-			return activeStorage
-		}
-		if v.Name == "set_data" {
-			k := fmt.Sprintf("%v", evalNode(v.Args[0], env))
-			val := evalNode(v.Args[1], env)
-
-			// Jika yang dikirim adalah Map atau Array, otomatis JSON-kan
-			var finalVal interface{}
-			switch val.(type) {
-			case map[string]interface{}, []interface{}:
-				jsonBytes, _ := json.Marshal(val)
-				finalVal = string(jsonBytes)
-			default:
-				finalVal = val
+		// Function milik script menang atas builtin bernama sama, supaya
+		// script yang punya count() atau date() sendiri tetap jalan
+		fn, isUser := env.GetFunc(v.Name)
+		b, isBuiltin := LookupBuiltin(v.Name)
+		isBuiltin = isBuiltin && !isUser
+		args := make([]interface{}, len(v.Args))
+		for i, a := range v.Args {
+			if isBuiltin && b.isRef(i) {
+				args[i] = newRef(a, env, v.Name, i)
+				continue
 			}
-
-			getStorage().Set(k, finalVal)
-			return true
-		}
-
-		if v.Name == "get_data" {
-			kEval := evalNode(v.Args[0], env)
-			if kEval == nil {
-				return ""
-			}
-
-			kStr := fmt.Sprintf("%v", kEval)
-			return getStorage().Get(kStr)
+			args[i] = evalNode(a, env)
 		}
-		if v.Name == "delete_data" {
-			if len(v.Args) < 1 {
-				return false
-			}
-			k := fmt.Sprintf("%v", evalNode(v.Args[0], env))
-			if k != "" && k != "<nil>" {
-				getStorage().Delete(k)
-				return true
-			}
-			return false
-		}
-		if v.Name == "drop_db" {
-			// Memanggil fungsi Drop() untuk menghapus file fisik database
-			err := getStorage().Drop()
-			if err != nil {
-				fmt.Printf("Gagal menghapus database: %v\n", err)
-				return false
-			}
-			return true
+		if isBuiltin {
+			return b.call(env, args)
 		}
-		fn, ok := env.GetFunc(v.Name)
-		if !ok {
+		if !isUser {
 			panic("undefined function: " + v.Name)
 		}
 		//fmt.Println("Memanggil fungsi:", v.Name, "dengan args:", v.Args)
+		return callFunction(fn, args, env)
 
-		local := NewChildEnv(env)
+	case FunctionLiteral:
+		return &Closure{Fn: v.Fn, Env: env}
 
-		for i, p := range fn.Params {
-			local.SetVar(p, evalNode(v.Args[i], env))
+	case DynamicCall:
+		callee := evalNode(v.Callee, env)
+		args := make([]interface{}, len(v.Args))
+		for i, a := range v.Args {
+			args[i] = evalNode(a, env)
 		}
-
-		for _, stmt := range fn.Body {
-			val := evalNode(stmt, local)
-			if rv, ok := val.(returnValue); ok {
-				return rv.value
-			}
-		}
-		return nil
+		return callValue(callee, args, env)
 
 	case Return:
 		val := evalNode(v.Value, env)
 		return returnValue{value: val}
-	case If:
-		cond := evalNode(v.Condition, env)
-		isTrue := false
-		if b, ok := cond.(bool); ok {
-			isTrue = b
-		} else if i, ok := cond.(int); ok {
-			isTrue = i > 0
+	case Yield:
+		if env.gen == nil {
+			panic("yield di luar generator")
 		}
-
-		if isTrue {
+		var key interface{}
+		if v.Key != nil {
+			key = evalNode(v.Key, env)
+		} else {
+			key = env.gen.autoKey
+			env.gen.autoKey++
+		}
+		env.gen.yield(key, evalNode(v.Value, env))
+		return nil
+	case If:
+		if isTruthy(evalNode(v.Condition, env)) {
 			for _, stmt := range v.Then {
 				res := evalNode(stmt, env)
 				// TAMBAHKAN INI: Jika ada return di dalam IF, teruskan ke atas
@@ -236,29 +404,32 @@ func evalNode(n Node, env *Env) interface{} {
 	case IndexAccess:
 		left := evalNode(v.Left, env)
 		index := evalNode(v.Index, env)
+		return indexValue(left, index)
+	case IndexAssign:
+		idxAccess := v.Left.(IndexAccess)
 
-		if m, ok := left.(map[string]interface{}); ok {
-			idxStr := fmt.Sprintf("%v", index)
-			return m[idxStr]
+		// Isi array konstanta juga tidak boleh diubah: CONFIG["a"] = 1
+		root := idxAccess.Left
+		for {
+			inner, ok := root.(IndexAccess)
+			if !ok {
+				break
+			}
+			root = inner.Left
 		}
-		// TAMBAHKAN INI: Support untuk Array/Slice
-		if s, ok := left.([]interface{}); ok {
-			if idxInt, ok := index.(int); ok {
-				if idxInt >= 0 && idxInt < len(s) {
-					return s[idxInt]
-				}
+		if rv, ok := root.(Identifier); ok && env.IsConst(rv.Name) {
+			if _, shadowed := env.lookupVar(rv.Name); !shadowed {
+				panic("tidak bisa mengubah konstanta " + rv.Name)
 			}
 		}
-		return nil
-	case IndexAssign:
-		idxAccess := v.Left.(IndexAccess)
+
 		leftVal := evalNode(idxAccess.Left, env)
 		index := evalNode(idxAccess.Index, env)
 		newVal := evalNode(v.Value, env)
 
 		// Jika target adalah MAP
 		if m, ok := leftVal.(map[string]interface{}); ok {
-			keyStr := fmt.Sprintf("%v", index)
+			keyStr := formatValue(index)
 			m[keyStr] = newVal
 			return newVal
 		}
@@ -275,73 +446,7 @@ func evalNode(n Node, env *Env) interface{} {
 		}
 		panic("Gagal melakukan update: target bukan map atau array")
 	case Serve:
-		portVal := evalNode(v.Port, env)
-		handlerName := v.Handler
-		addr := fmt.Sprintf("0.0.0.0:%v", portVal)
-
-		// Gunakan server mux lokal agar tidak bentrok jika serve dipanggil ulang
-		mux := http.NewServeMux()
-		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-			fn, ok := env.GetFunc(handlerName)
-			if !ok {
-				http.Error(w, fmt.Sprintf("Handler %s tidak ditemukan", handlerName), 404)
-				return
-			}
-
-			local := NewChildEnv(env)
-
-			// Setup variabel superglobal
-			monyetGet := make(map[string]interface{})
-			for key, values := range r.URL.Query() {
-				monyetGet[key] = values[0]
-				local.SetVar("GET_"+strings.ToUpper(key), values[0])
-			}
-			local.SetVar("_GET", monyetGet)
-			// --- 2. HANDLE REQUEST BODY (_POST, _PATCH, _DELETE) ---
-			// Kita asumsikan body yang dikirim adalah JSON
-			bodyData := make(map[string]interface{})
-			if r.Body != nil {
-				decoder := json.NewDecoder(r.Body)
-				// Kita tidak panic kalau decode gagal (misal body kosong)
-				_ = decoder.Decode(&bodyData)
-			}
-			local.SetVar("_POST", bodyData)
-			local.SetVar("_PATCH", bodyData)
-			local.SetVar("_DELETE", bodyData)
-			// Opsional: shortcut seperti POST_NAMA
-			for k, v := range bodyData {
-				prefix := r.Method + "_" // Akan jadi POST_, PATCH_, atau DELETE_
-				local.SetVar(prefix+strings.ToUpper(k), v)
-			}
-			local.SetVar("PATH", r.URL.Path)
-			local.SetVar("METHOD", r.Method)
-
-			var result interface{} = "" // Default kosong agar tidak <nil>
-			for _, stmt := range fn.Body {
-				val := evalNode(stmt, local)
-				if rv, ok := val.(returnValue); ok {
-					result = rv.value
-					break
-				}
-			}
-
-			// --- HANDLING OUTPUT & HEADER ---
-			resStr := fmt.Sprintf("%v", result)
-
-			// Deteksi JSON secara otomatis
-			if len(resStr) > 0 && (resStr[0] == '{' || resStr[0] == '[') {
-				w.Header().Set("Content-Type", "application/json")
-			} else {
-				w.Header().Set("Content-Type", "text/html; charset=utf-8")
-			}
-
-			fmt.Fprint(w, resStr)
-		})
-		err := http.ListenAndServe(addr, mux)
-		if err != nil {
-			fmt.Printf("Web Server Gagal: %v\n", err) // Tambahkan log ini
-		}
-		return nil
+		return evalServe(v, env)
 	case Include:
 		// Ambil base directory dari environment
 		baseDirVal, _ := env.GetVar("__BASE_DIR__")
@@ -364,40 +469,6 @@ func evalNode(n Node, env *Env) interface{} {
 		}
 		return nil
 
-	case Render:
-		pathVal := evalNode(v.Path, env).(string)
-
-		baseDirVal, _ := env.GetVar("__BASE_DIR__")
-		baseDir := baseDirVal.(string)
-
-		targetPath := filepath.Join(baseDir, pathVal)
-
-		content, err := os.ReadFile(targetPath)
-		if err != nil {
-			return fmt.Sprintf("Render Error: File %s tidak ditemukan", targetPath)
-		}
-		return string(content)
-	case JsonEncode:
-		dataVal := evalNode(v.Data, env)
-		jsonBytes, err := json.Marshal(dataVal)
-		if err != nil {
-			return fmt.Sprintf(`{"error": "%v"}`, err)
-		}
-		return string(jsonBytes)
-
-	case JsonDecode:
-		strVal := evalNode(v.Value, env)
-		str, ok := strVal.(string)
-		if !ok {
-			panic("json_decode membutuhkan input string")
-		}
-
-		var result interface{}
-		err := json.Unmarshal([]byte(str), &result)
-		if err != nil {
-			return nil
-		}
-		return result
 	case MapLiteral:
 		res := make(map[string]interface{})
 		for k, v := range v.Pairs {
@@ -406,13 +477,40 @@ func evalNode(n Node, env *Env) interface{} {
 			valEval := evalNode(v, env)
 
 			// Paksa key menjadi string agar aman untuk JSON
-			keyStr := fmt.Sprintf("%v", keyEval)
+			keyStr := formatValue(keyEval)
 			res[keyStr] = valEval
 		}
 		return res
 	case ForeachStatement:
 		iter := evalNode(v.Iterable, env)
 
+		// Generator dikonsumsi satu per satu, tidak pernah dikumpulkan dulu
+		if gen, ok := iter.(*Generator); ok {
+			defer gen.Close()
+			for {
+				k, item, ok := gen.Next()
+				if !ok {
+					return nil
+				}
+				local := NewChildEnv(env)
+				if v.Key != "" {
+					local.SetVar(v.Key, k)
+				}
+				if v.ValuePattern != nil {
+					destructure(*v.ValuePattern, item, local)
+				} else {
+					local.SetVar(v.Value, item)
+				}
+
+				for _, stmt := range v.Body {
+					res := evalNode(stmt, local)
+					if rv, ok := res.(returnValue); ok {
+						return rv
+					}
+				}
+			}
+		}
+
 		// Cek jika iterabel adalah Slice/Array
 		if list, ok := iter.([]interface{}); ok {
 			for i, item := range list {
@@ -420,7 +518,11 @@ func evalNode(n Node, env *Env) interface{} {
 				if v.Key != "" {
 					local.SetVar(v.Key, float64(i)) // index sebagai angka
 				}
-				local.SetVar(v.Value, item)
+				if v.ValuePattern != nil {
+					destructure(*v.ValuePattern, item, local)
+				} else {
+					local.SetVar(v.Value, item)
+				}
 
 				for _, stmt := range v.Body {
 					res := evalNode(stmt, local)
@@ -434,12 +536,17 @@ func evalNode(n Node, env *Env) interface{} {
 
 		// Cek jika iterabel adalah Map
 		if m, ok := iter.(map[string]interface{}); ok {
-			for k, val := range m {
+			for _, k := range orderedKeys(m) {
+				val := m[k]
 				local := NewChildEnv(env)
 				if v.Key != "" {
 					local.SetVar(v.Key, k)
 				}
-				local.SetVar(v.Value, val)
+				if v.ValuePattern != nil {
+					destructure(*v.ValuePattern, val, local)
+				} else {
+					local.SetVar(v.Value, val)
+				}
 
 				for _, stmt := range v.Body {
 					res := evalNode(stmt, local)
@@ -0,0 +1,78 @@
+package monyet
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTruthiness(t *testing.T) {
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{`1 && "x"`, true},
+		{`"x" && [1]`, true},
+		{`0 && true`, false},
+		{`"0" || ""`, false},
+		{`null || [1]`, true},
+		{`[] || 0`, false},
+		{`true && "0"`, false},
+		// berhenti lebih awal: sisi kanan tidak dijalankan
+		{`false && tidak_ada()`, false},
+		{`1 || tidak_ada()`, true},
+	}
+	for _, tt := range tests {
+		if got := evalExpr(t, tt.src); got != tt.want {
+			t.Errorf("%s = %#v, want %v", tt.src, got, tt.want)
+		}
+	}
+
+	// if memakai aturan yang sama
+	env := runScript(t, `
+$hasil = "tidak";
+if ("x") {
+    if (1 && "x") {
+        $hasil = "ya";
+    }
+}
+$kosong = "tidak";
+if ("0" || []) {
+    $kosong = "ya";
+}
+`)
+	if v, _ := env.GetVar("hasil"); v != "ya" {
+		t.Errorf(`if (1 && "x") tidak dijalankan`)
+	}
+	if v, _ := env.GetVar("kosong"); v != "tidak" {
+		t.Errorf(`if ("0" || []) dijalankan`)
+	}
+}
+
+func TestBareNames(t *testing.T) {
+	// nama function tanpa $ tetap bisa dipakai sebagai callable
+	env := runScript(t, `
+function double($x) { return $x * 2; }
+$hasil = array_map(double, [1, 2]);
+$jumlah = array_map(count, [[1], [1, 2]]);
+$baris = "a" + PHP_EOL;
+`)
+	if got := evalIn(env, `$hasil[1]`); got != 4.0 {
+		t.Errorf("array_map(double, ...)[1] = %#v, want 4", got)
+	}
+	if got := evalIn(env, `$jumlah[1]`); got != 2.0 {
+		t.Errorf("array_map(count, ...)[1] = %#v, want 2", got)
+	}
+	if got := evalIn(env, `$baris`); got != "a\n" {
+		t.Errorf("PHP_EOL = %#v", got)
+	}
+
+	// $nama yang belum ada tetap error walaupun namanya sama dengan function
+	for _, src := range []string{`$count`, `$double_typo`} {
+		if _, err := tryEval(NewEnv(), src); !strings.Contains(err, "undefined variable") {
+			t.Errorf("%s error = %q, want undefined variable", src, err)
+		}
+	}
+	if _, err := tryEval(NewEnv(), `tidak_ada_sama_sekali`); !strings.Contains(err, "undefined constant") {
+		t.Errorf("nama tanpa $ yang tidak dikenal error = %q", err)
+	}
+}
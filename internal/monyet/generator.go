@@ -0,0 +1,160 @@
+package monyet
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+// Generator adalah iterator lazy yang bisa dikonsumsi foreach. Sumbernya bisa
+// function MonyetLang yang berisi yield, atau fungsi Go (scan DB, baca file).
+type Generator struct {
+	next  func() (key, val interface{}, ok bool)
+	close func()
+	done  bool
+}
+
+// NewGenerator membuat generator dari fungsi Go. next mengembalikan ok=false
+// kalau data sudah habis; close (boleh nil) dipanggil sekali saat selesai.
+func NewGenerator(next func() (key, val interface{}, ok bool), close func()) *Generator {
+	return &Generator{next: next, close: close}
+}
+
+func (g *Generator) Next() (key, val interface{}, ok bool) {
+	if g.done {
+		return nil, nil, false
+	}
+	key, val, ok = g.next()
+	if !ok {
+		g.Close()
+	}
+	return key, val, ok
+}
+
+// Close menghentikan generator lebih awal, misalnya saat foreach keluar
+// karena return. Aman dipanggil berkali-kali.
+func (g *Generator) Close() {
+	if g.done {
+		return
+	}
+	g.done = true
+	if g.close != nil {
+		g.close()
+	}
+}
+
+// fileLines membaca file baris demi baris dari dalam root. File baru dibuka
+// saat baris pertama diminta, dan ditutup begitu generator selesai.
+func fileLines(root *os.Root, path string) *Generator {
+	var f *os.File
+	var scanner *bufio.Scanner
+	var line float64
+	return NewGenerator(func() (interface{}, interface{}, bool) {
+		if f == nil {
+			var err error
+			f, err = root.Open(path)
+			if err != nil {
+				panic(fmt.Sprintf("file_lines: tidak bisa membuka %s", path))
+			}
+			scanner = bufio.NewScanner(f)
+		}
+		if !scanner.Scan() {
+			return nil, nil, false
+		}
+		key := line
+		line++
+		return key, scanner.Text(), true
+	}, func() {
+		if f != nil {
+			f.Close()
+		}
+	})
+}
+
+type yieldItem struct {
+	key, val interface{}
+}
+
+// genState dibagikan ke semua Env di dalam body generator supaya node
+// Yield tahu ke mana harus mengirim nilai.
+type genState struct {
+	items   chan yieldItem
+	resume  chan bool // true = lanjut ke yield berikutnya, false = berhenti
+	failed  chan interface{}
+	autoKey float64
+}
+
+// generatorStop dipakai untuk membongkar goroutine generator yang dihentikan
+// sebelum body-nya selesai.
+type generatorStop struct{}
+
+// newFuncGenerator menjalankan body function di goroutine sendiri, tapi
+// bergantian dengan pemanggilnya: body hanya jalan sampai yield berikutnya
+// setiap kali Next dipanggil, jadi tidak pernah ada dua yang jalan bersamaan.
+func newFuncGenerator(body []Node, env *Env) *Generator {
+	st := &genState{
+		items:  make(chan yieldItem),
+		resume: make(chan bool),
+		failed: make(chan interface{}, 1),
+	}
+	env.gen = st
+
+	run := func() {
+		defer func() {
+			if r := recover(); r != nil {
+				if _, stop := r.(generatorStop); !stop {
+					st.failed <- r
+				}
+			}
+			close(st.items)
+		}()
+		if !<-st.resume {
+			return
+		}
+		for _, stmt := range body {
+			if _, ok := evalNode(stmt, env).(returnValue); ok {
+				return
+			}
+		}
+	}
+
+	started := false
+	next := func() (interface{}, interface{}, bool) {
+		if !started {
+			started = true
+			go run()
+		}
+		st.resume <- true
+		item, ok := <-st.items
+		if !ok {
+			// Error di dalam generator diteruskan ke pemanggil
+			select {
+			case r := <-st.failed:
+				panic(r)
+			default:
+			}
+			return nil, nil, false
+		}
+		return item.key, item.val, true
+	}
+	stop := func() {
+		if !started {
+			return
+		}
+		select {
+		case st.resume <- false:
+		case <-st.items:
+		}
+		for range st.items {
+		}
+	}
+	return &Generator{next: next, close: stop}
+}
+
+// yield dipanggil dari goroutine generator: kirim nilai lalu tunggu giliran.
+func (st *genState) yield(key, val interface{}) {
+	st.items <- yieldItem{key: key, val: val}
+	if !<-st.resume {
+		panic(generatorStop{})
+	}
+}
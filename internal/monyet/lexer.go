@@ -68,6 +68,8 @@ func (l *Lexer) NextToken() Token {
 		return Token{Type: ASSIGN, Value: "="}
 	case ';':
 		return Token{Type: SEMICOLON, Value: ";"}
+	case ':':
+		return Token{Type: COLON, Value: ":"}
 	case '(':
 		return Token{Type: LPAREN, Value: "("}
 	case ')':
@@ -122,27 +124,24 @@ func (l *Lexer) NextToken() Token {
 		if ident == "else" {
 			return Token{Type: ELSE, Value: ident}
 		}
-		if ident == "serve" {
+		if ident == "serve" || ident == "serve_tls" {
 			return Token{Type: SERVE, Value: ident}
 		}
 		if ident == "include" {
 			return Token{Type: INCLUDE, Value: ident}
 		}
-		if ident == "render" {
-			return Token{Type: RENDER, Value: ident}
-		}
-		if ident == "json_encode" {
-			return Token{Type: JSON_ENCODE, Value: ident}
-		}
-		if ident == "json_decode" {
-			return Token{Type: JSON_DECODE, Value: ident}
-		}
 		if ident == "foreach" {
 			return Token{Type: FOREACH, Value: ident}
 		}
 		if ident == "as" {
 			return Token{Type: AS, Value: ident}
 		}
+		if ident == "const" {
+			return Token{Type: CONST, Value: ident}
+		}
+		if ident == "yield" {
+			return Token{Type: YIELD, Value: ident}
+		}
 
 		return Token{Type: IDENT, Value: ident}
 	}
@@ -0,0 +1,406 @@
+package monyet
+
+import (
+	"compress/gzip"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// middleware adalah satu lapisan di sekitar handler: function script yang
+// menerima $next, atau middleware Go dari RegisterMiddleware.
+type middleware struct {
+	name   string
+	script interface{}
+	wrap   func(http.Handler) http.Handler
+}
+
+// MiddlewareFactory membuat middleware Go dari opsi yang diberikan script,
+// misalnya use("cors", ["origin" => "https://contoh.com"]).
+type MiddlewareFactory func(opts map[string]interface{}) func(http.Handler) http.Handler
+
+var goMiddlewares = map[string]MiddlewareFactory{}
+
+// RegisterMiddleware mendaftarkan middleware Go supaya bisa dipakai dari
+// script lewat use("nama").
+func RegisterMiddleware(name string, f MiddlewareFactory) {
+	goMiddlewares[name] = f
+}
+
+func init() {
+	RegisterBuiltin(Builtin{Name: "use", MinArgs: 1, MaxArgs: 2, Params: []string{"callable", "array"}, Returns: "bool", Fn: builtinUse})
+	RegisterBuiltin(Builtin{Name: "middleware", MinArgs: 1, MaxArgs: 2, Params: []string{"callable", "array"}, Returns: "bool", Fn: builtinUse})
+
+	RegisterMiddleware("logger", loggerMiddleware)
+	RegisterMiddleware("recover", recoverMiddleware)
+	RegisterMiddleware("cors", corsMiddleware)
+	RegisterMiddleware("gzip", gzipMiddleware)
+	RegisterMiddleware("request_id", requestIDMiddleware)
+}
+
+// builtinUse: use("auth") atau use(fn($next) => ...) untuk middleware
+// script, use("cors", $opsi) untuk middleware Go. Di luar group berlaku
+// untuk semua route, di dalam group() hanya untuk route di group itu.
+// Function script dengan nama yang sama menang atas middleware Go.
+func builtinUse(env *Env, args []interface{}) interface{} {
+	callee := args[0]
+	var mw middleware
+	if name, ok := callee.(string); ok {
+		if _, isFunc := env.GetFunc(name); !isFunc {
+			factory, ok := goMiddlewares[name]
+			if !ok {
+				panic(fmt.Sprintf("use(): middleware %s tidak ditemukan", name))
+			}
+			opts := map[string]interface{}{}
+			if len(args) > 1 && args[1] != nil {
+				m, ok := args[1].(map[string]interface{})
+				if !ok {
+					panic(fmt.Sprintf("use(): opsi middleware %s harus array, dapat %s", name, typeOf(args[1])))
+				}
+				opts = m
+			}
+			mw = middleware{name: name, wrap: factory(opts)}
+		}
+	}
+	if mw.wrap == nil {
+		fn, _, ok := resolveHandler(callee, env)
+		if !ok {
+			panic(fmt.Sprintf("use(): %s bukan function", handlerLabel(callee)))
+		}
+		if len(fn.Params) == 0 {
+			panic(fmt.Sprintf("use(): middleware %s harus menerima $next", handlerLabel(callee)))
+		}
+		mw = middleware{name: handlerLabel(callee), script: callee}
+	}
+
+	router := routerOf(env)
+	router.mu.Lock()
+	defer router.mu.Unlock()
+	if n := len(router.groupMiddleware); n > 0 {
+		router.groupMiddleware[n-1] = append(router.groupMiddleware[n-1], mw)
+	} else {
+		router.middleware = append(router.middleware, mw)
+	}
+	return true
+}
+
+// wrapHTTP memasang middleware Go dari chain ke handler. Yang didaftarkan
+// lebih dulu menjadi lapisan paling luar.
+func wrapHTTP(chain []middleware, h http.Handler) http.Handler {
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i].wrap != nil {
+			h = chain[i].wrap(h)
+		}
+	}
+	return h
+}
+
+// runMiddleware menjalankan middleware script ke-i. $next menjalankan sisa
+// chain, dan setelah middleware terakhir, handler itu sendiri. Nilai yang
+// di-return middleware menjadi response, jadi middleware bisa memotong
+// request (tidak memanggil $next) atau mengubah hasil handler.
+func runMiddleware(chain []middleware, i int, env, local *Env, handler func() interface{}) interface{} {
+	for i < len(chain) && chain[i].script == nil {
+		i++
+	}
+	if i == len(chain) {
+		return handler()
+	}
+
+	fn, base, _ := resolveHandler(chain[i].script, env)
+	// Middleware jalan di scope-nya sendiri, tapi melihat superglobal
+	// request yang sama dengan handler
+	scope := NewChildEnv(base)
+	scope.req = local.req
+	for k, v := range local.vars {
+		scope.vars[k] = v
+	}
+
+	next := &NativeFunc{Name: "next", Fn: func(args []interface{}) interface{} {
+		return runMiddleware(chain, i+1, env, local, handler)
+	}}
+	args := make([]interface{}, len(fn.Params))
+	args[0] = next
+	return callFunction(fn, args, scope)
+}
+
+// statusWriter mencatat status dan jumlah byte yang dikirim.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// loggerMiddleware menulis satu baris log per request ke stderr:
+// GET /user/1 200 52B 1.2ms
+func loggerMiddleware(opts map[string]interface{}) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w}
+			defer func() {
+				status := sw.status
+				if status == 0 {
+					status = http.StatusOK
+				}
+				log.Printf("%s %s %d %dB %s", r.Method, r.URL.RequestURI(), status, sw.bytes, time.Since(start).Round(time.Microsecond))
+			}()
+			next.ServeHTTP(sw, r)
+		})
+	}
+}
+
+// recoverMiddleware mengubah panic di handler menjadi response 500 supaya
+// satu request yang error tidak memutus koneksi. Opsi "debug" => true
+// menampilkan pesan panic di body.
+func recoverMiddleware(opts map[string]interface{}) func(http.Handler) http.Handler {
+	debug := isTruthy(opts["debug"])
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw := &statusWriter{ResponseWriter: w}
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+				log.Printf("panic: %s %s: %v", r.Method, r.URL.Path, v)
+				// Kalau header sudah terkirim, response tidak bisa diganti lagi
+				if sw.status != 0 {
+					return
+				}
+				msg := http.StatusText(http.StatusInternalServerError)
+				if debug {
+					msg = fmt.Sprint(v)
+				}
+				http.Error(sw, msg, http.StatusInternalServerError)
+			}()
+			next.ServeHTTP(sw, r)
+		})
+	}
+}
+
+// optionList membaca opsi berupa array atau string dipisah koma.
+func optionList(opts map[string]interface{}, key string, def []string) []string {
+	v, ok := opts[key]
+	if !ok || v == nil {
+		return def
+	}
+	var out []string
+	if list := listValues(v); list != nil {
+		for _, item := range list {
+			out = append(out, argString(item))
+		}
+		return out
+	}
+	for _, item := range strings.Split(argString(v), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// corsMiddleware menambahkan header CORS dan menjawab preflight OPTIONS.
+// Opsi: origin (default "*"), methods, headers, expose, credentials, max_age.
+func corsMiddleware(opts map[string]interface{}) func(http.Handler) http.Handler {
+	origins := optionList(opts, "origin", []string{"*"})
+	methods := optionList(opts, "methods", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"})
+	headers := optionList(opts, "headers", nil)
+	expose := optionList(opts, "expose", nil)
+	credentials := isTruthy(opts["credentials"])
+	maxAge := ""
+	if v, ok := opts["max_age"]; ok {
+		maxAge = strconv.Itoa(toInt(v))
+	}
+
+	allowAll := false
+	for _, o := range origins {
+		if o == "*" {
+			allowAll = true
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			allowed := allowAll
+			for _, o := range origins {
+				if strings.EqualFold(o, origin) {
+					allowed = true
+				}
+			}
+			if origin == "" || !allowed {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// "*" tidak boleh dipakai bersama credentials, jadi origin-nya dipantulkan
+			if allowAll && !credentials {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if credentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+			if len(expose) > 0 {
+				h.Set("Access-Control-Expose-Headers", strings.Join(expose, ", "))
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				h.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+				if len(headers) > 0 {
+					h.Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+				} else if req := r.Header.Get("Access-Control-Request-Headers"); req != "" {
+					h.Set("Access-Control-Allow-Headers", req)
+				}
+				if maxAge != "" {
+					h.Set("Access-Control-Max-Age", maxAge)
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// gzipWriter baru memutuskan kompres atau tidak saat header dikirim,
+// karena baru saat itu Content-Type dan status dari handler diketahui.
+type gzipWriter struct {
+	http.ResponseWriter
+	level   int
+	gz      *gzip.Writer
+	decided bool
+}
+
+func (w *gzipWriter) WriteHeader(code int) {
+	if !w.decided {
+		w.decided = true
+		h := w.Header()
+		// 206 dan response yang sudah punya Content-Range tidak dikompres,
+		// karena rentang byte-nya menunjuk ke body asli
+		if code != http.StatusNoContent && code != http.StatusNotModified && code != http.StatusPartialContent &&
+			h.Get("Content-Encoding") == "" && h.Get("Content-Range") == "" && compressible(h.Get("Content-Type")) {
+			h.Set("Content-Encoding", "gzip")
+			h.Del("Content-Length")
+			w.gz, _ = gzip.NewWriterLevel(w.ResponseWriter, w.level)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *gzipWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *gzipWriter) Flush() {
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *gzipWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *gzipWriter) Close() {
+	if w.gz != nil {
+		w.gz.Close()
+	}
+}
+
+// compressible: gambar, video, audio dan arsip biasanya sudah terkompres.
+func compressible(contentType string) bool {
+	ct := strings.ToLower(contentType)
+	for _, prefix := range []string{"image/", "video/", "audio/", "application/zip", "application/gzip", "application/x-gzip"} {
+		if strings.HasPrefix(ct, prefix) && ct != "image/svg+xml" {
+			return false
+		}
+	}
+	return true
+}
+
+// gzipMiddleware mengompres response kalau client mendukung gzip.
+// Opsi: level (1-9, default gzip.DefaultCompression).
+func gzipMiddleware(opts map[string]interface{}) func(http.Handler) http.Handler {
+	level := gzip.DefaultCompression
+	if v, ok := opts["level"]; ok {
+		level = toInt(v)
+		if level < gzip.BestSpeed || level > gzip.BestCompression {
+			panic(fmt.Sprintf("use(): level gzip harus 1-9, dapat %d", level))
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			gw := &gzipWriter{ResponseWriter: w, level: level}
+			defer gw.Close()
+			next.ServeHTTP(gw, r)
+		})
+	}
+}
+
+// requestIDMiddleware memberi setiap request ID unik di header X-Request-Id
+// (request dan response). ID dari client dipakai ulang kalau ada, jadi
+// handler bisa membacanya lewat $_HEADERS["X-Request-Id"].
+// Opsi: header untuk mengganti nama header.
+func requestIDMiddleware(opts map[string]interface{}) func(http.Handler) http.Handler {
+	header := "X-Request-Id"
+	if v, ok := opts["header"]; ok {
+		header = http.CanonicalHeaderKey(argString(v))
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(header)
+			if id == "" || len(id) > 200 {
+				id = newUUID()
+				r.Header.Set(header, id)
+			}
+			w.Header().Set(header, id)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package monyet
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGzipSkipsPartialContent(t *testing.T) {
+	body := strings.Repeat("monyet ", 200)
+	h := gzipMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		if r.Header.Get("Range") != "" {
+			w.Header().Set("Content-Range", "bytes 0-9/1400")
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write([]byte(body[:10]))
+			return
+		}
+		w.Write([]byte(body))
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatal("response 200 teks harus dikompres")
+	}
+
+	req.Header.Set("Range", "bytes=0-9")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusPartialContent || rec.Header().Get("Content-Encoding") != "" {
+		t.Errorf("206 tidak boleh dikompres: status %d, encoding %q", rec.Code, rec.Header().Get("Content-Encoding"))
+	}
+	if rec.Body.String() != body[:10] {
+		t.Errorf("body 206 = %q", rec.Body.String())
+	}
+}
@@ -8,6 +8,9 @@ import (
 type Parser struct {
 	l   *Lexer
 	cur Token
+
+	funcDepth int  // kedalaman function yang sedang di-parse
+	sawYield  bool // apakah function saat ini berisi yield
 }
 
 func NewParser(l *Lexer) *Parser {
@@ -50,6 +53,18 @@ func (p *Parser) parseStatement() Node {
 	case RETURN:
 		p.next()
 		return Return{Value: p.parseExpr()}
+	case YIELD:
+		if p.funcDepth == 0 {
+			panic("yield hanya boleh dipakai di dalam function")
+		}
+		p.sawYield = true
+		p.next() // makan 'yield'
+		val := p.parseExpr()
+		if p.cur.Type == ARROW {
+			p.next() // makan =>
+			return Yield{Key: val, Value: p.parseExpr()}
+		}
+		return Yield{Value: val}
 	case ECHO:
 		p.next()
 		return Echo{Value: p.parseExpr()}
@@ -61,6 +76,11 @@ func (p *Parser) parseStatement() Node {
 		// 1. Inisialisasi node awal sebagai variabel
 		var node Node = Variable{Name: name}
 
+		// Memanggil callable yang disimpan di variabel: $next();
+		if p.cur.Type == LPAREN {
+			return DynamicCall{Callee: node, Args: p.parseCallArgs()}
+		}
+
 		// 2. Cek apakah ada bracket (IndexAccess)
 		// Kita pakai FOR supaya bisa handle nested: $a["b"]["c"]
 		for p.cur.Type == LBRACKET {
@@ -90,7 +110,20 @@ func (p *Parser) parseStatement() Node {
 		name := p.cur.Value
 		p.next()
 
-		var node Node = Variable{Name: name}
+		// Deklarasi variabel bertipe: int $a = 1;
+		if p.cur.Type == DOLLAR {
+			typ := p.expectType(name, false)
+			p.next() // makan $
+			varName := p.cur.Value
+			p.next() // makan nama variabel
+			if p.cur.Type != ASSIGN {
+				panic("Expected = after typed variable $" + varName)
+			}
+			p.next() // makan =
+			return Assign{Name: varName, Value: p.parseExpr(), Type: typ}
+		}
+
+		var node Node = Identifier{Name: name}
 
 		// --- TAMBAHKAN INI UNTUK MENANGANI _GET["nama"] ---
 		for p.cur.Type == LBRACKET {
@@ -124,16 +157,6 @@ func (p *Parser) parseStatement() Node {
 			return Assign{Name: name, Value: p.parseExpr()}
 		}
 
-		// Logika khusus untuk render("file.html")
-		if name == "render" && p.cur.Type == LBRACKET || p.cur.Type == LPAREN {
-			p.next() // makan (
-			pathExpr := p.parseExpr()
-			if p.cur.Type == RPAREN {
-				p.next() // makan )
-			}
-			return Render{Path: pathExpr}
-		}
-
 		return node
 	case SERVE:
 		return p.parseServe()
@@ -150,8 +173,28 @@ func (p *Parser) parseStatement() Node {
 			p.next()
 		}
 		return Include{Path: path}
+	case LBRACKET:
+		// [$a, $b] = $list; atau ["nama" => $n] = $_POST;
+		lit := p.parseMapLiteral().(MapLiteral)
+		if p.cur.Type != ASSIGN {
+			return lit
+		}
+		p.next() // makan =
+		return DestructureAssign{Pattern: p.toPattern(lit), Value: p.parseExpr()}
 	case FOREACH:
 		return p.parseForeach()
+	case CONST:
+		p.next() // makan 'const'
+		if p.cur.Type != IDENT {
+			panic("Expected constant name after const")
+		}
+		name := p.cur.Value
+		p.next() // makan nama konstanta
+		if p.cur.Type != ASSIGN {
+			panic("Expected = after const " + name)
+		}
+		p.next() // makan =
+		return ConstDecl{Name: name, Value: p.parseExpr()}
 	default:
 		// Jika sampai sini, berarti ada token yang Parser tidak tahu cara handle-nya
 		fmt.Printf("WARNING: Parser tidak tahu cara menangani token %s\n", p.cur.Type)
@@ -182,6 +225,11 @@ func (p *Parser) parseFactor() Node {
 		name := p.cur.Value
 		p.next() // makan nama
 		node = Variable{Name: name}
+		if p.cur.Type == LPAREN {
+			node = DynamicCall{Callee: node, Args: p.parseCallArgs()}
+		}
+	case FUNCTION:
+		node = p.parseFunction()
 	case NUMBER:
 		p.next()
 		val, _ := strconv.ParseFloat(tok.Value, 64)
@@ -189,6 +237,10 @@ func (p *Parser) parseFactor() Node {
 	case STRING:
 		p.next()
 		node = String{Value: tok.Value}
+	case MINUS:
+		// Minus unary: -5 atau -$x, dihitung sebagai 0 - x
+		p.next()
+		node = Binary{Left: Number{Value: 0}, Op: MINUS, Right: p.parseFactor()}
 	case LBRACKET:
 		node = p.parseMapLiteral()
 	case IDENT:
@@ -206,34 +258,8 @@ func (p *Parser) parseFactor() Node {
 			p.next() // )
 			node = Call{Name: name, Args: args}
 		} else {
-			node = Variable{Name: name}
-		}
-	case RENDER:
-		p.next() // render
-		p.next() // (
-		path := p.parseExpr()
-		p.next() // )
-		node = Render{Path: path}
-	case JSON_ENCODE:
-		p.next() // json_encode
-		if p.cur.Type == LPAREN {
-			p.next()
-		}
-		data := p.parseExpr()
-		if p.cur.Type == RPAREN {
-			p.next()
-		}
-		node = JsonEncode{Data: data}
-	case JSON_DECODE:
-		p.next() // json_decode
-		if p.cur.Type == LPAREN {
-			p.next()
-		}
-		val := p.parseExpr()
-		if p.cur.Type == RPAREN {
-			p.next()
+			node = Identifier{Name: name}
 		}
-		node = JsonDecode{Value: val}
 	}
 
 	for p.cur.Type == LBRACKET {
@@ -249,6 +275,20 @@ func (p *Parser) parseFactor() Node {
 	return node
 }
 
+// parseCallArgs mem-parse (arg1, arg2, ...) termasuk kurungnya.
+func (p *Parser) parseCallArgs() []Node {
+	p.next() // makan (
+	args := []Node{}
+	for p.cur.Type != RPAREN && p.cur.Type != EOF {
+		args = append(args, p.parseExpr())
+		if p.cur.Type == COMMA {
+			p.next()
+		}
+	}
+	p.next() // makan )
+	return args
+}
+
 func (p *Parser) parseExpr() Node {
 	return p.parseLogical()
 }
@@ -278,21 +318,48 @@ func (p *Parser) parseAdditive() Node {
 func (p *Parser) parseFunction() Node {
 	p.next() // makan 'function' atau 'fn'
 
+	// Tanpa nama berarti function anonim: function ($x) { ... } atau fn($x) => ...
+	if p.cur.Type == LPAREN {
+		return FunctionLiteral{Fn: p.parseFunctionRest("")}
+	}
+
 	name := p.cur.Value
 	p.next() // makan nama fungsi
+	return p.parseFunctionRest(name)
+}
 
+// parseFunctionRest mem-parse parameter, tipe return dan body function.
+func (p *Parser) parseFunctionRest(name string) Function {
 	if p.cur.Type != LPAREN {
 		panic("Expected ( after function name")
 	}
 	p.next() // makan '('
 
 	params := []string{}
+	paramTypes := []string{}
 	for p.cur.Type != RPAREN && p.cur.Type != EOF {
+		typ := ""
+		if p.cur.Type == IDENT {
+			// Bisa jadi anotasi tipe (int $a) atau nama param tanpa $ (a)
+			tok := p.cur
+			p.next()
+			if p.cur.Type == DOLLAR || p.cur.Type == IDENT {
+				typ = p.expectType(tok.Value, false)
+			} else {
+				params = append(params, tok.Value)
+				paramTypes = append(paramTypes, "")
+				if p.cur.Type == COMMA {
+					p.next()
+				}
+				continue
+			}
+		}
 		// Jika parameter menggunakan $, sesuaikan di sini
 		if p.cur.Type == DOLLAR {
 			p.next() // skip $
 		}
 		params = append(params, p.cur.Value)
+		paramTypes = append(paramTypes, typ)
 		p.next()
 		if p.cur.Type == COMMA {
 			p.next()
@@ -304,11 +371,30 @@ func (p *Parser) parseFunction() Node {
 	}
 	p.next() // makan ')'
 
+	// Tipe return opsional: function add(int $a, int $b): int
+	returnType := ""
+	if p.cur.Type == COLON {
+		p.next() // makan ':'
+		returnType = p.expectType(p.cur.Value, true)
+		p.next() // makan nama tipe
+	}
+
+	// Arrow function: fn($x) => $x * 2, body-nya satu ekspresi yang di-return
+	if p.cur.Type == ARROW {
+		p.next() // makan =>
+		body := []Node{Return{Value: p.parseExpr()}}
+		return Function{Name: name, Params: params, ParamTypes: paramTypes, ReturnType: returnType, Body: body}
+	}
+
 	if p.cur.Type != LBRACE {
 		panic("Expected { before function body")
 	}
 	p.next() // makan '{'
 
+	outerYield := p.sawYield
+	p.sawYield = false
+	p.funcDepth++
+
 	body := []Node{}
 	for p.cur.Type != RBRACE && p.cur.Type != EOF {
 		stmt := p.parseStatement()
@@ -325,7 +411,18 @@ func (p *Parser) parseFunction() Node {
 	}
 	p.next() // makan '}'
 
-	return Function{Name: name, Params: params, Body: body}
+	p.funcDepth--
+	isGenerator := p.sawYield
+	p.sawYield = outerYield
+
+	return Function{Name: name, Params: params, ParamTypes: paramTypes, ReturnType: returnType, Body: body, Generator: isGenerator}
+}
+
+func (p *Parser) expectType(name string, isReturn bool) string {
+	if !isValidType(name, isReturn) {
+		panic("Unknown type: " + name)
+	}
+	return name
 }
 
 func (p *Parser) parseIf() Node {
@@ -390,28 +487,80 @@ func (p *Parser) parseIf() Node {
 }
 
 func (p *Parser) parseServe() Node {
-	p.next() // makan 'serve'
+	name := p.cur.Value
+	p.next() // makan 'serve' / 'serve_tls'
 	if p.cur.Type != LPAREN {
-		panic("Kurang ( di serve")
+		panic("Kurang ( di " + name)
 	}
 	p.next()
 
 	port := p.parseExpr()
 
-	if p.cur.Type != COMMA {
-		panic("Kurang koma setelah port di serve")
+	if name == "serve_tls" {
+		return p.parseServeTLS(port)
 	}
-	p.next()
 
-	handlerName := p.cur.Value // Ambil nama fungsi
-	p.next()
+	// serve(8080) tanpa handler berarti pakai route() yang sudah didaftarkan.
+	// Opsi server boleh langsung setelah port: serve(8080, ["idle_timeout" => 60])
+	handlerName := ""
+	var options Node
+	if p.cur.Type == COMMA {
+		p.next()
+		if p.cur.Type == LBRACKET {
+			options = p.parseExpr()
+		} else {
+			handlerName = p.parseServeHandler()
+			if p.cur.Type == COMMA {
+				p.next()
+				options = p.parseExpr()
+			}
+		}
+	}
 
 	if p.cur.Type != RPAREN {
 		panic("Kurang ) di serve")
 	}
 	p.next()
 
-	return Serve{Port: port, Handler: handlerName}
+	return Serve{Port: port, Handler: handlerName, Options: options}
+}
+
+// parseServeTLS: serve_tls(port, handler, cert, key[, opsi]). Handler wajib
+// ditulis, pakai null kalau memakai route().
+func (p *Parser) parseServeTLS(port Node) Node {
+	v := Serve{Port: port}
+	p.expectServeArg("handler")
+	v.Handler = p.parseServeHandler()
+	p.expectServeArg("cert")
+	v.Cert = p.parseExpr()
+	p.expectServeArg("key")
+	v.Key = p.parseExpr()
+	if p.cur.Type == COMMA {
+		p.next()
+		v.Options = p.parseExpr()
+	}
+
+	if p.cur.Type != RPAREN {
+		panic("Kurang ) di serve_tls")
+	}
+	p.next()
+	return v
+}
+
+func (p *Parser) expectServeArg(arg string) {
+	if p.cur.Type != COMMA {
+		panic("serve_tls butuh argumen " + arg)
+	}
+	p.next()
+}
+
+func (p *Parser) parseServeHandler() string {
+	handlerName := p.cur.Value // Ambil nama fungsi
+	if handlerName == "null" {
+		handlerName = ""
+	}
+	p.next()
+	return handlerName
 }
 
 func (p *Parser) parseLogical() Node {
@@ -480,24 +629,37 @@ func (p *Parser) parseForeach() Node {
 
 	// Handle key => value atau cuma value
 	var keyName, valName string
+	var pattern *ListPattern
 
-	if p.cur.Type == DOLLAR {
-		p.next()
-	}
-	firstIdent := p.cur.Value
-	p.next()
-
-	if p.cur.Type == ARROW { // Jika ada =>
-		p.next() // makan =>
-		keyName = firstIdent
+	if p.cur.Type == LBRACKET {
+		// foreach ($rows as [$a, $b])
+		pat := p.toPattern(p.parseMapLiteral().(MapLiteral))
+		pattern = &pat
+	} else {
 		if p.cur.Type == DOLLAR {
 			p.next()
 		}
-		valName = p.cur.Value
+		firstIdent := p.cur.Value
 		p.next()
-	} else {
-		keyName = "" // tidak ada key
-		valName = firstIdent
+
+		if p.cur.Type == ARROW { // Jika ada =>
+			p.next() // makan =>
+			keyName = firstIdent
+			if p.cur.Type == LBRACKET {
+				// foreach ($rows as $id => ["nama" => $n])
+				pat := p.toPattern(p.parseMapLiteral().(MapLiteral))
+				pattern = &pat
+			} else {
+				if p.cur.Type == DOLLAR {
+					p.next()
+				}
+				valName = p.cur.Value
+				p.next()
+			}
+		} else {
+			keyName = "" // tidak ada key
+			valName = firstIdent
+		}
 	}
 
 	if p.cur.Type != RPAREN {
@@ -523,9 +685,29 @@ func (p *Parser) parseForeach() Node {
 	p.next() // makan }
 
 	return ForeachStatement{
-		Iterable: iterable,
-		Key:      keyName,
-		Value:    valName,
-		Body:     body,
+		Iterable:     iterable,
+		Key:          keyName,
+		Value:        valName,
+		ValuePattern: pattern,
+		Body:         body,
+	}
+}
+
+// toPattern mengubah literal [..] yang ada di sebelah kiri = menjadi pola
+// destructuring. Slot kosong seperti [, $b] dilewati.
+func (p *Parser) toPattern(lit MapLiteral) ListPattern {
+	pat := ListPattern{}
+	for key, target := range lit.Pairs {
+		switch t := target.(type) {
+		case nil:
+			continue
+		case Variable:
+			pat.Items = append(pat.Items, PatternItem{Key: key, Target: t})
+		case MapLiteral:
+			pat.Items = append(pat.Items, PatternItem{Key: key, Target: p.toPattern(t)})
+		default:
+			panic("Destructuring target harus variabel atau [..]")
+		}
 	}
+	return pat
 }
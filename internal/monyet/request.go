@@ -0,0 +1,183 @@
+package monyet
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterBuiltin(Builtin{Name: "getallheaders", MinArgs: 0, MaxArgs: 0, Returns: "array", Fn: builtinGetallheaders})
+}
+
+// setupRequestVars mengisi superglobal untuk satu request:
+// $_GET, $_POST (juga $_PATCH/$_DELETE), $_FILES, $_SERVER, $_HEADERS,
+// $_COOKIE, $RAW_BODY, $PATH, $METHOD dan $PARAMS. Error hanya dikembalikan
+// kalau body melewati post_max_size; body yang rusak cukup dianggap kosong.
+func setupRequestVars(local *Env, r *http.Request, params map[string]interface{}) error {
+	query := formValues(r.URL.Query())
+	local.SetVar("_GET", query)
+	for k, v := range query {
+		local.SetVar("GET_"+strings.ToUpper(k), v)
+	}
+
+	rawBody, body, files, err := readRequestBody(local, r)
+	var tooBig *http.MaxBytesError
+	if errors.As(err, &tooBig) {
+		return err
+	}
+	local.SetVar("RAW_BODY", rawBody)
+	local.SetVar("_FILES", files)
+	local.SetVar("_POST", body)
+	local.SetVar("_PATCH", body)
+	local.SetVar("_DELETE", body)
+	// Opsional: shortcut seperti POST_NAMA
+	if m, ok := body.(map[string]interface{}); ok {
+		for k, v := range m {
+			prefix := r.Method + "_" // Akan jadi POST_, PATCH_, atau DELETE_
+			local.SetVar(prefix+strings.ToUpper(k), v)
+		}
+	}
+
+	cookies := map[string]interface{}{}
+	for _, c := range r.Cookies() {
+		cookies[c.Name] = c.Value
+	}
+	local.SetVar("_COOKIE", cookies)
+	local.SetVar("_HEADERS", headerMap(r))
+	local.SetVar("_SERVER", serverVars(r))
+
+	local.SetVar("PATH", r.URL.Path)
+	local.SetVar("METHOD", r.Method)
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	local.SetVar("PARAMS", params)
+	return nil
+}
+
+// formValues mengubah url.Values menjadi map. Key yang muncul sekali
+// menjadi string, key yang muncul berkali-kali (?tag=a&tag=b) atau ditulis
+// gaya PHP (tag[]=a) menjadi array.
+func formValues(values url.Values) map[string]interface{} {
+	out := map[string]interface{}{}
+	for key, vals := range values {
+		name, isList := strings.CutSuffix(key, "[]")
+		if len(vals) == 1 && !isList {
+			out[name] = vals[0]
+			continue
+		}
+		list := make([]interface{}, len(vals))
+		for i, v := range vals {
+			list[i] = v
+		}
+		out[name] = list
+	}
+	return out
+}
+
+// readRequestBody membaca body sesuai Content-Type: form urlencoded dan
+// multipart masuk ke $_POST sebagai field, JSON di-decode. Body lain hanya
+// tersedia mentah di $RAW_BODY. Untuk multipart $RAW_BODY kosong karena
+// body-nya sudah dikonsumsi parser, sama seperti php://input di PHP, dan
+// file upload-nya masuk ke $_FILES.
+func readRequestBody(env *Env, r *http.Request) (string, interface{}, map[string]interface{}, error) {
+	empty := map[string]interface{}{}
+	files := map[string]interface{}{}
+	if r.Body == nil {
+		return "", empty, files, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		fields, uploaded, err := parseMultipart(env, r)
+		if err != nil {
+			return "", empty, files, err
+		}
+		return "", fields, uploaded, nil
+	}
+
+	raw, err := io.ReadAll(r.Body)
+	if err != nil || len(raw) == 0 {
+		return string(raw), empty, files, err
+	}
+
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		vals, err := url.ParseQuery(string(raw))
+		if err != nil {
+			return string(raw), empty, files, nil
+		}
+		return string(raw), formValues(vals), files, nil
+	case "application/json", "":
+		// Tanpa Content-Type tetap dicoba sebagai JSON, seperti dulu
+		var data interface{}
+		if err := json.Unmarshal(raw, &data); err == nil && data != nil {
+			return string(raw), data, files, nil
+		}
+	}
+	return string(raw), empty, files, nil
+}
+
+// headerMap mengembalikan header request dengan nama kanonik
+// (Content-Type, X-Request-Id). Header yang berulang digabung dengan koma.
+func headerMap(r *http.Request) map[string]interface{} {
+	out := map[string]interface{}{}
+	for k, vals := range r.Header {
+		out[k] = strings.Join(vals, ", ")
+	}
+	if r.Host != "" {
+		out["Host"] = r.Host
+	}
+	return out
+}
+
+// serverVars membentuk $_SERVER dengan nama key yang sama seperti PHP.
+func serverVars(r *http.Request) map[string]interface{} {
+	now := time.Now()
+	host, port, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host, port = r.RemoteAddr, ""
+	}
+	serverName := r.Host
+	if h, _, err := net.SplitHostPort(r.Host); err == nil {
+		serverName = h
+	}
+
+	out := map[string]interface{}{
+		"REQUEST_METHOD":     r.Method,
+		"REQUEST_URI":        r.URL.RequestURI(),
+		"PATH_INFO":          r.URL.Path,
+		"QUERY_STRING":       r.URL.RawQuery,
+		"SERVER_PROTOCOL":    r.Proto,
+		"REMOTE_ADDR":        host,
+		"REMOTE_PORT":        port,
+		"HTTP_HOST":          r.Host,
+		"SERVER_NAME":        serverName,
+		"REQUEST_SCHEME":     "http",
+		"REQUEST_TIME":       float64(now.Unix()),
+		"REQUEST_TIME_FLOAT": float64(now.UnixNano()) / 1e9,
+	}
+	if r.TLS != nil {
+		out["HTTPS"] = "on"
+		out["REQUEST_SCHEME"] = "https"
+	}
+	for k, vals := range r.Header {
+		key := strings.ToUpper(strings.ReplaceAll(k, "-", "_"))
+		if key != "CONTENT_TYPE" && key != "CONTENT_LENGTH" {
+			key = "HTTP_" + key
+		}
+		out[key] = strings.Join(vals, ", ")
+	}
+	return out
+}
+
+func builtinGetallheaders(env *Env, args []interface{}) interface{} {
+	return headerMap(currentRequest(env, "getallheaders").r)
+}
@@ -0,0 +1,159 @@
+package monyet
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const requestScript = `
+route("GET", "/query", fn() => json_encode(["get" => _GET, "tag" => GET_TAG, "kosong" => GET_TIDAK_ADA]));
+route("POST|PUT|PATCH", "/body", fn() => json_encode(["post" => _POST, "raw" => $RAW_BODY, "nama" => POST_NAMA]));
+route("GET", "/server", fn() => json_encode(_SERVER));
+route("GET", "/headers", fn() => json_encode(getallheaders()));
+`
+
+// requestJSON mengirim request ke requestScript dan men-decode body JSON-nya.
+func requestJSON(t *testing.T, h http.Handler, req *http.Request) map[string]interface{} {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Fatalf("%s %s = %d %q", req.Method, req.URL, rec.Code, rec.Body.String())
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("%s %s: body bukan JSON: %q", req.Method, req.URL, rec.Body.String())
+	}
+	return out
+}
+
+func TestRequestQuery(t *testing.T) {
+	_, h := routerHandler(t, requestScript)
+	tests := []struct {
+		query string
+		want  map[string]interface{}
+	}{
+		{"a=1&b=dua", map[string]interface{}{"a": "1", "b": "dua"}},
+		{"tag=a&tag=b", map[string]interface{}{"tag": []interface{}{"a", "b"}}},
+		{"tag[]=a", map[string]interface{}{"tag": []interface{}{"a"}}},
+		{"tag[]=a&tag[]=b&x=%20y", map[string]interface{}{"tag": []interface{}{"a", "b"}, "x": " y"}},
+		{"", map[string]interface{}{}},
+	}
+	for _, tt := range tests {
+		out := requestJSON(t, h, httptest.NewRequest("GET", "/query?"+tt.query, nil))
+		got, _ := out["get"].(map[string]interface{})
+		if got == nil {
+			got = map[string]interface{}{}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("?%s: _GET = %#v, want %#v", tt.query, out["get"], tt.want)
+		}
+		if out["kosong"] != "" {
+			t.Errorf("?%s: GET_TIDAK_ADA = %#v, want \"\"", tt.query, out["kosong"])
+		}
+	}
+
+	out := requestJSON(t, h, httptest.NewRequest("GET", "/query?tag=x", nil))
+	if out["tag"] != "x" {
+		t.Errorf("GET_TAG = %#v, want x", out["tag"])
+	}
+}
+
+func TestRequestBody(t *testing.T) {
+	_, h := routerHandler(t, requestScript)
+	tests := []struct {
+		method, contentType, body string
+		post                      interface{}
+	}{
+		{"POST", "application/x-www-form-urlencoded", "nama=budi&tag[]=a&tag[]=b",
+			map[string]interface{}{"nama": "budi", "tag": []interface{}{"a", "b"}}},
+		{"POST", "application/json", `{"nama":"budi","umur":30,"hobi":["a"]}`,
+			map[string]interface{}{"nama": "budi", "umur": 30.0, "hobi": []interface{}{"a"}}},
+		{"PUT", "application/json; charset=utf-8", `[1,2]`, []interface{}{1.0, 2.0}},
+		// tanpa Content-Type tetap dicoba sebagai JSON
+		{"PATCH", "", `{"nama":"ani"}`, map[string]interface{}{"nama": "ani"}},
+		// body rusak dianggap kosong (json_encode menulis [] untuk array
+		// kosong), isinya tetap ada di $RAW_BODY
+		{"POST", "application/json", `{rusak`, []interface{}{}},
+		{"POST", "text/plain", "halo", []interface{}{}},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/body", strings.NewReader(tt.body))
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		out := requestJSON(t, h, req)
+		if !reflect.DeepEqual(out["post"], tt.post) {
+			t.Errorf("%s %s %s: _POST = %#v, want %#v", tt.method, tt.contentType, tt.body, out["post"], tt.post)
+		}
+		if out["raw"] != tt.body {
+			t.Errorf("%s %s: $RAW_BODY = %#v, want %q", tt.method, tt.contentType, out["raw"], tt.body)
+		}
+	}
+
+	req := httptest.NewRequest("POST", "/body", strings.NewReader("nama=budi"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if out := requestJSON(t, h, req); out["nama"] != "budi" {
+		t.Errorf("POST_NAMA = %#v, want budi", out["nama"])
+	}
+}
+
+func TestRequestServerVars(t *testing.T) {
+	_, h := routerHandler(t, requestScript)
+
+	req := httptest.NewRequest("GET", "http://contoh.test:8080/server?a=1", nil)
+	req.RemoteAddr = "10.0.0.5:51234"
+	req.Header.Set("User-Agent", "tes/1.0")
+	req.Header.Set("X-Request-Id", "abc")
+	req.Header.Set("Content-Type", "text/plain")
+	got := requestJSON(t, h, req)
+	want := map[string]interface{}{
+		"REQUEST_METHOD":    "GET",
+		"REQUEST_URI":       "/server?a=1",
+		"PATH_INFO":         "/server",
+		"QUERY_STRING":      "a=1",
+		"SERVER_PROTOCOL":   "HTTP/1.1",
+		"REMOTE_ADDR":       "10.0.0.5",
+		"REMOTE_PORT":       "51234",
+		"HTTP_HOST":         "contoh.test:8080",
+		"SERVER_NAME":       "contoh.test",
+		"REQUEST_SCHEME":    "http",
+		"HTTP_USER_AGENT":   "tes/1.0",
+		"HTTP_X_REQUEST_ID": "abc",
+		"CONTENT_TYPE":      "text/plain",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("_SERVER[%q] = %#v, want %#v", k, got[k], v)
+		}
+	}
+	if _, ok := got["HTTPS"]; ok {
+		t.Error("_SERVER[HTTPS] ada tanpa TLS")
+	}
+	if n, _ := got["REQUEST_TIME"].(float64); n <= 0 {
+		t.Errorf("REQUEST_TIME = %#v", got["REQUEST_TIME"])
+	}
+
+	req = httptest.NewRequest("GET", "https://contoh.test/server", nil)
+	req.TLS = &tls.ConnectionState{}
+	got = requestJSON(t, h, req)
+	if got["HTTPS"] != "on" || got["REQUEST_SCHEME"] != "https" || got["SERVER_NAME"] != "contoh.test" {
+		t.Errorf("dengan TLS: HTTPS=%#v REQUEST_SCHEME=%#v SERVER_NAME=%#v", got["HTTPS"], got["REQUEST_SCHEME"], got["SERVER_NAME"])
+	}
+}
+
+func TestGetallheaders(t *testing.T) {
+	_, h := routerHandler(t, requestScript)
+	req := httptest.NewRequest("GET", "http://contoh.test/headers", nil)
+	req.Header.Add("x-tag", "a")
+	req.Header.Add("X-Tag", "b")
+	got := requestJSON(t, h, req)
+	if got["X-Tag"] != "a, b" || got["Host"] != "contoh.test" {
+		t.Errorf("getallheaders() = %#v", got)
+	}
+}
@@ -0,0 +1,531 @@
+package monyet
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Router menyimpan route yang didaftarkan lewat route()/group(). Route
+// langsung dipasang ke http.ServeMux saat didaftarkan, jadi pola yang
+// bentrok langsung ketahuan di baris route() yang salah. ServeMux juga yang
+// mengurus 404 dan 405 (lengkap dengan header Allow).
+type Router struct {
+	mu       sync.Mutex
+	mux      *http.ServeMux
+	routes   []routeInfo
+	prefixes []string // stack prefix dari group() yang sedang berjalan
+	fallback bool     // handler catch-all serve(port, handler) sudah dipasang
+
+	middleware      []middleware   // use() di luar group, berlaku untuk semua route
+	groupMiddleware [][]middleware // use() di dalam group, sejajar dengan prefixes
+}
+
+type routeInfo struct {
+	Method  string
+	Path    string
+	Handler string
+}
+
+var routeParamPattern = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)(\.\.\.)?\}`)
+
+func init() {
+	RegisterBuiltin(Builtin{Name: "route", MinArgs: 3, MaxArgs: 3, Params: []string{"", "string", "callable"}, Returns: "bool", Fn: builtinRoute})
+	RegisterBuiltin(Builtin{Name: "group", MinArgs: 2, MaxArgs: 2, Params: []string{"string", "callable"}, Fn: builtinGroup})
+	RegisterBuiltin(Builtin{Name: "routes", MinArgs: 0, MaxArgs: 0, Returns: "array", Fn: builtinRoutes})
+}
+
+// routerOf mengembalikan router milik script (disimpan di scope paling
+// luar), dibuat saat pertama kali dibutuhkan.
+func routerOf(env *Env) *Router {
+	root := env.root()
+	if v, ok := root.GetVar("__ROUTER__"); ok {
+		if r, ok := v.(*Router); ok {
+			return r
+		}
+	}
+	r := &Router{mux: http.NewServeMux()}
+	root.SetVar("__ROUTER__", r)
+	return r
+}
+
+// joinRoutePath menggabungkan prefix group dengan path route. Path yang
+// diakhiri "/" dibuat exact match ({$}), karena di ServeMux pola seperti
+// itu berarti "semua path di bawahnya". Pakai {path...} untuk wildcard.
+func joinRoutePath(prefixes []string, path string) string {
+	full := ""
+	for _, p := range prefixes {
+		full += strings.TrimSuffix(p, "/")
+	}
+	if full != "" && path == "/" {
+		path = ""
+	}
+	full += path
+	if full == "" {
+		full = "/"
+	}
+	if strings.HasSuffix(full, "/") {
+		full += "{$}"
+	}
+	return full
+}
+
+func handlerLabel(callee interface{}) string {
+	switch c := callee.(type) {
+	case string:
+		return c
+	case *Closure:
+		if c.Fn.Name != "" {
+			return c.Fn.Name
+		}
+		return "closure"
+	}
+	return typeOf(callee)
+}
+
+// builtinRoute: route("GET", "/user/{id}", "show_user"). Method boleh
+// "GET|POST", array ["GET", "POST"], atau "ANY"/"*" untuk semua method.
+func builtinRoute(env *Env, args []interface{}) interface{} {
+	var methods []string
+	if list := listValues(args[0]); list != nil {
+		for _, m := range list {
+			methods = append(methods, argString(m))
+		}
+	} else {
+		methods = strings.Split(argString(args[0]), "|")
+	}
+
+	path := args[1].(string)
+	if !strings.HasPrefix(path, "/") {
+		panic(fmt.Sprintf("route(): path %q harus diawali /", path))
+	}
+
+	router := routerOf(env)
+	router.mu.Lock()
+	defer router.mu.Unlock()
+
+	full := joinRoutePath(router.prefixes, path)
+	var names []string
+	for _, m := range routeParamPattern.FindAllStringSubmatch(full, -1) {
+		names = append(names, m[1])
+	}
+
+	var chain []middleware
+	for _, mws := range router.groupMiddleware {
+		chain = append(chain, mws...)
+	}
+
+	callee := args[2]
+	handler := wrapHTTP(chain, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := map[string]interface{}{}
+		for _, name := range names {
+			params[name] = r.PathValue(name)
+		}
+		handleRequest(w, r, env, callee, params, chain)
+	}))
+
+	for _, m := range methods {
+		method := strings.ToUpper(strings.TrimSpace(m))
+		pattern := method + " " + full
+		if method == "ANY" || method == "*" || method == "" {
+			method, pattern = "ANY", full
+		}
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					panic(fmt.Sprintf("route(): %s %s: %v", method, full, r))
+				}
+			}()
+			router.mux.Handle(pattern, handler)
+		}()
+		router.routes = append(router.routes, routeInfo{Method: method, Path: full, Handler: handlerLabel(callee)})
+	}
+	return true
+}
+
+// builtinGroup: group("/api", fn() => route("GET", "/users", "list_users")).
+// Semua route di dalam callback diberi prefix; group boleh bersarang.
+func builtinGroup(env *Env, args []interface{}) interface{} {
+	prefix := args[0].(string)
+	if !strings.HasPrefix(prefix, "/") {
+		panic(fmt.Sprintf("group(): prefix %q harus diawali /", prefix))
+	}
+	router := routerOf(env)
+	router.mu.Lock()
+	router.prefixes = append(router.prefixes, prefix)
+	router.groupMiddleware = append(router.groupMiddleware, nil)
+	router.mu.Unlock()
+	defer func() {
+		router.mu.Lock()
+		router.prefixes = router.prefixes[:len(router.prefixes)-1]
+		router.groupMiddleware = router.groupMiddleware[:len(router.groupMiddleware)-1]
+		router.mu.Unlock()
+	}()
+	return callValue(args[1], nil, env)
+}
+
+// builtinRoutes mengembalikan daftar route untuk debugging.
+func builtinRoutes(env *Env, args []interface{}) interface{} {
+	router := routerOf(env)
+	router.mu.Lock()
+	defer router.mu.Unlock()
+	out := make([]interface{}, len(router.routes))
+	for i, rt := range router.routes {
+		out[i] = map[string]interface{}{"method": rt.Method, "path": rt.Path, "handler": rt.Handler}
+	}
+	return out
+}
+
+// serverOptions adalah pengaturan http.Server dari argumen ketiga serve()
+// atau config server.* (config menang, sama seperti port).
+type serverOptions struct {
+	readTimeout       time.Duration
+	readHeaderTimeout time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	shutdownTimeout   time.Duration
+	maxHeaderBytes    int
+
+	// HTTPS: aktif kalau tlsCert dan tlsKey diisi. redirectHTTP adalah port
+	// HTTP yang mengarahkan semua request ke HTTPS.
+	tlsCert      string
+	tlsKey       string
+	redirectHTTP string
+}
+
+// Default read/write tanpa batas supaya upload besar dan response lambat
+// tetap jalan; yang dibatasi hanya header dan koneksi keep-alive yang diam.
+var defaultServerOptions = serverOptions{
+	readHeaderTimeout: 10 * time.Second,
+	idleTimeout:       120 * time.Second,
+	shutdownTimeout:   10 * time.Second,
+	maxHeaderBytes:    http.DefaultMaxHeaderBytes,
+}
+
+// parseServerOptions menggabungkan opsi dari script dengan config.
+// cert dan key adalah argumen serve_tls(), nil untuk serve().
+func parseServerOptions(env *Env, raw, cert, key interface{}) serverOptions {
+	opts := map[string]interface{}{}
+	if raw != nil {
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			panic(fmt.Sprintf("serve: opsi harus array, dapat %s", typeOf(raw)))
+		}
+		for k, v := range m {
+			opts[k] = v
+		}
+	}
+	if cert != nil || key != nil {
+		opts["tls_cert"], opts["tls_key"] = cert, key
+	}
+
+	o := defaultServerOptions
+	durations := map[string]*time.Duration{
+		"read_timeout":        &o.readTimeout,
+		"read_header_timeout": &o.readHeaderTimeout,
+		"write_timeout":       &o.writeTimeout,
+		"idle_timeout":        &o.idleTimeout,
+		"shutdown_timeout":    &o.shutdownTimeout,
+	}
+	strs := map[string]*string{
+		"tls_cert":      &o.tlsCert,
+		"tls_key":       &o.tlsKey,
+		"redirect_http": &o.redirectHTTP,
+	}
+	known := func(k string) bool {
+		_, isDuration := durations[k]
+		_, isString := strs[k]
+		return isDuration || isString || k == "max_header_size"
+	}
+	for k := range opts {
+		if !known(k) {
+			panic(fmt.Sprintf("serve: opsi %s tidak dikenal", k))
+		}
+	}
+	cfg := scriptConfig(env)
+	for _, k := range cfg.Keys() {
+		if name, ok := strings.CutPrefix(k, "server_"); ok && known(name) {
+			if v, ok := cfg.Setting(k); ok {
+				opts[name] = v
+			}
+		}
+	}
+
+	// Timeout dalam detik (boleh pecahan), 0 berarti tanpa batas
+	for key, d := range durations {
+		if v, ok := opts[key]; ok {
+			*d = time.Duration(toNumber(v) * float64(time.Second))
+		}
+	}
+	switch v := opts["max_header_size"].(type) {
+	case nil:
+	case float64:
+		o.maxHeaderBytes = int(v)
+	default:
+		o.maxHeaderBytes = int(parseSize(argString(v), int64(o.maxHeaderBytes)))
+	}
+	for key, str := range strs {
+		*str = argString(opts[key])
+	}
+
+	if (o.tlsCert == "") != (o.tlsKey == "") {
+		panic("serve: tls_cert dan tls_key harus diisi keduanya")
+	}
+	if o.redirectHTTP != "" && o.tlsCert == "" {
+		panic("serve: redirect_http hanya bisa dipakai bersama TLS")
+	}
+	// Path sertifikat relatif terhadap folder script
+	for _, p := range []*string{&o.tlsCert, &o.tlsKey} {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(baseDir(env), *p)
+		}
+	}
+	return o
+}
+
+// evalServe menjalankan web server. serve(8080) melayani route yang sudah
+// didaftarkan; serve(8080, handler) memakai handler sebagai catch-all
+// (dan sebagai fallback kalau ada route yang tidak cocok).
+// serve_tls(8443, handler, cert, key) sama, tapi lewat HTTPS.
+//
+// SIGINT/SIGTERM menghentikan server dengan rapi: koneksi baru ditolak,
+// request yang sedang berjalan ditunggu sampai shutdown_timeout, lalu
+// callback on_shutdown() dijalankan dan database ditutup. Sinyal kedua
+// langsung mematikan proses.
+func evalServe(v Serve, env *Env) interface{} {
+	portVal := evalNode(v.Port, env)
+	// Port dari config (.env, env var, --set) menang atas literal di script
+	if port := configString(env, "port"); port != "" {
+		portVal = port
+	}
+	port := fmt.Sprint(portVal)
+	addr := "0.0.0.0:" + port
+
+	var rawOpts, cert, key interface{}
+	if v.Options != nil {
+		rawOpts = evalNode(v.Options, env)
+	}
+	if v.Cert != nil {
+		cert, key = evalNode(v.Cert, env), evalNode(v.Key, env)
+	}
+	opts := parseServerOptions(env, rawOpts, cert, key)
+	if v.Cert != nil && opts.tlsCert == "" {
+		panic("serve_tls: path cert dan key tidak boleh kosong")
+	}
+
+	router := routerOf(env)
+	router.mu.Lock()
+	if v.Handler != "" && !router.fallback {
+		handlerName := v.Handler
+		router.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			handleRequest(w, r, env, handlerName, nil, nil)
+		})
+		router.fallback = true
+	}
+	empty := len(router.routes) == 0 && !router.fallback
+	// Middleware Go global membungkus seluruh mux, jadi ikut berlaku untuk
+	// 404, 405 dan preflight CORS
+	handler := wrapHTTP(router.middleware, router.mux)
+	router.mu.Unlock()
+	if empty {
+		panic("serve: belum ada route, daftarkan dengan route() atau pakai serve(port, handler)")
+	}
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       opts.readTimeout,
+		ReadHeaderTimeout: opts.readHeaderTimeout,
+		WriteTimeout:      opts.writeTimeout,
+		IdleTimeout:       opts.idleTimeout,
+		MaxHeaderBytes:    opts.maxHeaderBytes,
+	}
+	servers := []*http.Server{srv}
+	listen := srv.ListenAndServe
+
+	if opts.tlsCert != "" {
+		certs, err := newCertReloader(opts.tlsCert, opts.tlsKey)
+		if err != nil {
+			panic(fmt.Sprintf("serve: sertifikat TLS gagal dimuat: %v", err))
+		}
+		srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: certs.getCertificate}
+		listen = func() error { return srv.ListenAndServeTLS("", "") }
+
+		if opts.redirectHTTP != "" {
+			servers = append(servers, &http.Server{
+				Addr:              "0.0.0.0:" + opts.redirectHTTP,
+				Handler:           redirectHTTPS(port),
+				ReadHeaderTimeout: opts.readHeaderTimeout,
+				IdleTimeout:       opts.idleTimeout,
+			})
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errc := make(chan error, len(servers))
+	go func() { errc <- listen() }()
+	for _, s := range servers[1:] {
+		go func() { errc <- s.ListenAndServe() }()
+	}
+
+	select {
+	case err := <-errc:
+		// Port dipakai atau tidak boleh dibuka: matikan listener lain
+		// (misalnya redirect_http) dan keluar dengan kode error
+		log.Printf("Web Server Gagal: %v", err)
+		for _, s := range servers {
+			s.Close()
+		}
+		panic(ExitSignal{Code: 1})
+	case <-ctx.Done():
+	}
+	stop()
+
+	log.Println("Server berhenti, menunggu request yang masih berjalan...")
+	sctx, cancel := context.WithCancel(context.Background())
+	if opts.shutdownTimeout > 0 {
+		sctx, cancel = context.WithTimeout(context.Background(), opts.shutdownTimeout)
+	}
+	defer cancel()
+	for _, s := range servers {
+		if err := s.Shutdown(sctx); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				log.Printf("Shutdown melewati %v, koneksi yang tersisa diputus", opts.shutdownTimeout)
+			}
+			s.Close()
+		}
+	}
+	Shutdown(env)
+	// Script berhenti di sini seperti exit(), bukan lanjut ke baris setelah serve
+	panic(ExitSignal{Code: 0})
+}
+
+// resolveHandler mencari function handler dan env tempat ia dijalankan:
+// closure memakai env tempat ia dibuat, function bernama memakai env global.
+func resolveHandler(callee interface{}, env *Env) (Function, *Env, bool) {
+	switch c := callee.(type) {
+	case *Closure:
+		return c.Fn, c.Env, true
+	case string:
+		fn, ok := env.GetFunc(c)
+		return fn, env, ok
+	}
+	return Function{}, nil, false
+}
+
+// pathArg mengubah path param (selalu string) ke tipe parameter handler.
+// ok false kalau nilainya tidak bisa diubah, misalnya "abc" untuk int.
+func pathArg(typ string, raw interface{}) (interface{}, bool) {
+	s, ok := raw.(string)
+	if !ok {
+		return raw, true
+	}
+	switch typ {
+	case "int":
+		n, err := strconv.ParseInt(s, 10, 64)
+		// float64 hanya bisa menyimpan bilangan bulat sampai 2^53 dengan tepat
+		if err != nil || n > 1<<53 || n < -(1<<53) {
+			return nil, false
+		}
+		return float64(n), true
+	case "float":
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, false
+		}
+		return f, true
+	case "bool":
+		switch strings.ToLower(s) {
+		case "1", "true":
+			return true, true
+		case "0", "false":
+			return false, true
+		}
+		return nil, false
+	}
+	return s, true
+}
+
+// handleRequest menyiapkan superglobal untuk satu request, memanggil
+// handler lewat middleware script (global dulu, lalu milik group), lalu
+// menulis hasilnya lewat writeResponse.
+func handleRequest(w http.ResponseWriter, r *http.Request, env *Env, callee interface{}, params map[string]interface{}, chain []middleware) {
+	fn, base, ok := resolveHandler(callee, env)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Handler %s tidak ditemukan", handlerLabel(callee)), 404)
+		return
+	}
+
+	local := NewChildEnv(base)
+	ctx := &requestContext{w: w, r: r, status: http.StatusOK}
+	local.req = ctx
+
+	// Body dibatasi post_max_size; file upload yang tidak dipindahkan lewat
+	// move_uploaded_file() dihapus setelah handler selesai
+	r.Body = http.MaxBytesReader(w, r.Body, postMaxSize(local))
+	defer ctx.cleanupUploads()
+	if err := setupRequestVars(local, r, params); err != nil {
+		http.Error(w, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// Parameter handler diisi dari path param dengan nama yang sama:
+	// route("GET", "/user/{id}", fn($id) => ...). Param bertipe diubah dulu,
+	// jadi function(int $id) menerima 42; "/user/abc" dijawab 404.
+	args := make([]interface{}, len(fn.Params))
+	for i, p := range fn.Params {
+		args[i] = params[p]
+		if i >= len(fn.ParamTypes) || fn.ParamTypes[i] == "" {
+			continue
+		}
+		typ := fn.ParamTypes[i]
+		raw, inPath := params[p]
+		if !inPath || typ == "array" || typ == "iterable" {
+			log.Printf("handler %s: parameter $%s bertipe %s tidak bisa diisi dari path route", handlerLabel(callee), p, typ)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		val, ok := pathArg(typ, raw)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		args[i] = val
+	}
+
+	router := routerOf(env)
+	router.mu.Lock()
+	chain = append(append([]middleware{}, router.middleware...), chain...)
+	router.mu.Unlock()
+
+	var result interface{}
+	func() {
+		// exit() di handler cukup mengakhiri request ini
+		defer func() {
+			if r := recover(); r != nil {
+				if _, ok := r.(ExitSignal); !ok {
+					panic(r)
+				}
+			}
+		}()
+		result = runMiddleware(chain, 0, env, local, func() interface{} {
+			return callFunction(fn, args, local)
+		})
+	}()
+
+	writeResponse(ctx, result)
+}
@@ -0,0 +1,142 @@
+package monyet
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const routerScript = `
+function show_user(int $id) {
+    $next = $id + 1;
+    return "user " + $next;
+}
+route("GET", "/user/{id}", "show_user");
+route("GET", "/price/{p}", function(float $p) {
+    $d = $p * 2;
+    return "double " + $d;
+});
+route("GET", "/flag/{on}", function(bool $on) {
+    return json_encode($on);
+});
+route("GET", "/typo", function(int $id) {
+    return "tidak pernah";
+});
+route("POST|PUT", "/item/{id}", fn($id) => "item " + $id);
+route("ANY", "/files/{path...}", fn($path) => $path);
+group("/api", function() {
+    route("GET", "/ping", fn() => "pong");
+    group("/v1/", function() {
+        route("GET", "/users/{id}", function(int $id) {
+            return "v1 user " + $id;
+        });
+    });
+});
+`
+
+// routerHandler menyiapkan handler seperti serve(port) tanpa handler.
+func routerHandler(t *testing.T, src string) (*Env, http.Handler) {
+	t.Helper()
+	env := runScript(t, src)
+	router := routerOf(env)
+	return env, wrapHTTP(router.middleware, router.mux)
+}
+
+func TestRouter(t *testing.T) {
+	_, h := routerHandler(t, routerScript)
+	tests := []struct {
+		method, path string
+		code         int
+		body         string
+	}{
+		{"GET", "/user/42", 200, "user 43"},
+		{"GET", "/user/abc", 404, ""},
+		{"GET", "/user/1.5", 404, ""},
+		{"GET", "/user/99999999999999999999", 404, ""},
+		{"GET", "/price/2.5", 200, "double 5"},
+		{"GET", "/price/x", 404, ""},
+		{"GET", "/flag/true", 200, "true"},
+		{"GET", "/flag/0", 200, "false"},
+		{"GET", "/flag/ya", 404, ""},
+		{"GET", "/typo", 500, ""},
+		{"PUT", "/item/7", 200, "item 7"},
+		{"POST", "/item/7", 200, "item 7"},
+		{"GET", "/item/7", 405, ""},
+		{"DELETE", "/files/a/b.txt", 200, "a/b.txt"},
+		{"GET", "/api/ping", 200, "pong"},
+		{"GET", "/api/v1/users/3", 200, "v1 user 3"},
+		{"GET", "/api/v1/users/x", 404, ""},
+		{"GET", "/ping", 404, ""},
+		{"GET", "/tidak-ada", 404, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%s %s = %d, want %d (%q)", tt.method, tt.path, rec.Code, tt.code, rec.Body.String())
+			continue
+		}
+		if tt.body != "" && rec.Body.String() != tt.body {
+			t.Errorf("%s %s body = %q, want %q", tt.method, tt.path, rec.Body.String(), tt.body)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/item/7", nil))
+	if allow := rec.Header().Get("Allow"); !strings.Contains(allow, "POST") || !strings.Contains(allow, "PUT") {
+		t.Errorf("405 Allow = %q", allow)
+	}
+}
+
+func TestRoutesList(t *testing.T) {
+	env, _ := routerHandler(t, routerScript)
+	list := evalIn(env, `routes()`).([]interface{})
+	if len(list) != 9 {
+		t.Fatalf("routes() berisi %d route, want 9: %v", len(list), list)
+	}
+	want := []map[string]interface{}{
+		{"method": "GET", "path": "/user/{id}", "handler": "show_user"},
+		{"method": "POST", "path": "/item/{id}", "handler": "closure"},
+		{"method": "PUT", "path": "/item/{id}", "handler": "closure"},
+		{"method": "ANY", "path": "/files/{path...}", "handler": "closure"},
+		{"method": "GET", "path": "/api/ping", "handler": "closure"},
+		{"method": "GET", "path": "/api/v1/users/{id}", "handler": "closure"},
+	}
+	for _, w := range want {
+		found := false
+		for _, got := range list {
+			if reflect.DeepEqual(got, w) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("routes() tidak berisi %v", w)
+		}
+	}
+}
+
+func TestPathArg(t *testing.T) {
+	tests := []struct {
+		typ, raw string
+		want     interface{}
+		ok       bool
+	}{
+		{"int", "42", 42.0, true},
+		{"int", "-7", -7.0, true},
+		{"int", "4.2", nil, false},
+		{"int", "9007199254740993", nil, false},
+		{"float", "1e3", 1000.0, true},
+		{"float", "NaN", nil, false},
+		{"bool", "FALSE", false, true},
+		{"string", "abc", "abc", true},
+		{"mixed", "abc", "abc", true},
+	}
+	for _, tt := range tests {
+		got, ok := pathArg(tt.typ, tt.raw)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("pathArg(%s, %q) = %#v, %v", tt.typ, tt.raw, got, ok)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package monyet
+
+import (
+	"fmt"
+	"sync"
+)
+
+func init() {
+	RegisterBuiltin(Builtin{Name: "shared_get", MinArgs: 1, MaxArgs: 2, Params: []string{"string"}, Fn: builtinSharedGet})
+	RegisterBuiltin(Builtin{Name: "shared_set", MinArgs: 2, MaxArgs: 2, Params: []string{"string"}, Returns: "bool", Fn: builtinSharedSet})
+	RegisterBuiltin(Builtin{Name: "shared_has", MinArgs: 1, MaxArgs: 1, Params: []string{"string"}, Returns: "bool", Fn: builtinSharedHas})
+	RegisterBuiltin(Builtin{Name: "shared_delete", MinArgs: 1, MaxArgs: 1, Params: []string{"string"}, Returns: "bool", Fn: builtinSharedDelete})
+	RegisterBuiltin(Builtin{Name: "shared_incr", MinArgs: 1, MaxArgs: 2, Params: []string{"string", "float"}, Returns: "float", Fn: builtinSharedIncr})
+	RegisterBuiltin(Builtin{Name: "shared_update", MinArgs: 2, MaxArgs: 2, Params: []string{"string", "callable"}, Fn: builtinSharedUpdate})
+	RegisterBuiltin(Builtin{Name: "shared_lock", MinArgs: 2, MaxArgs: 2, Params: []string{"string", "callable"}, Fn: builtinSharedLock})
+}
+
+// sharedVar membaca variabel dari scope bersama. Array disalin sekali per
+// request supaya perubahan isinya ($cfg["x"] = 1) tidak menyentuh map yang
+// sedang dibaca request lain.
+func (ctx *requestContext) sharedVar(scope *Env, name string) (interface{}, bool) {
+	if v, ok := ctx.globals[scope][name]; ok {
+		return v, true
+	}
+	v, ok := scope.vars[name]
+	if !ok {
+		return nil, false
+	}
+	if isArrayValue(v) {
+		v = deepCopy(v)
+		ctx.setSharedVar(scope, name, v)
+	}
+	return v, true
+}
+
+func (ctx *requestContext) setSharedVar(scope *Env, name string, val interface{}) {
+	if ctx.globals == nil {
+		ctx.globals = map[*Env]map[string]interface{}{}
+	}
+	if ctx.globals[scope] == nil {
+		ctx.globals[scope] = map[string]interface{}{}
+	}
+	ctx.globals[scope][name] = val
+}
+
+// constant sama seperti sharedVar untuk konstanta: konstanta array
+// disalin supaya $c = CONFIG; $c["x"] = 1; tidak mengubah CONFIG.
+func (ctx *requestContext) constant(name string, shared map[string]interface{}) (interface{}, bool) {
+	if v, ok := ctx.consts[name]; ok {
+		return v, true
+	}
+	v, ok := shared[name]
+	if ok && isArrayValue(v) {
+		v = deepCopy(v)
+		ctx.defineConst(name, v)
+	}
+	return v, ok
+}
+
+func (ctx *requestContext) defineConst(name string, val interface{}) {
+	if ctx.consts == nil {
+		ctx.consts = map[string]interface{}{}
+	}
+	ctx.consts[name] = val
+}
+
+func (ctx *requestContext) defineFunc(name string, fn Function) {
+	if ctx.funcs == nil {
+		ctx.funcs = map[string]Function{}
+	}
+	ctx.funcs[name] = fn
+}
+
+func isArrayValue(v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	}
+	return false
+}
+
+// deepCopy menyalin array beserta isinya. Nilai lain (angka, string,
+// closure, resource) dipakai apa adanya.
+func deepCopy(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, item := range t {
+			out[k] = deepCopy(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, item := range t {
+			out[i] = deepCopy(item)
+		}
+		return out
+	}
+	return v
+}
+
+// sharedStore adalah data yang sengaja dibagi antar request (counter,
+// cache kecil, rate limit). Nilai selalu disalin saat masuk dan keluar,
+// jadi tidak ada map yang dipegang dua request sekaligus.
+var sharedStore = struct {
+	mu     sync.Mutex
+	values map[string]interface{}
+	locks  map[string]*sync.Mutex
+}{values: map[string]interface{}{}, locks: map[string]*sync.Mutex{}}
+
+// sharedValue menyalin nilai untuk disimpan. Closure dan resource ditolak
+// karena membawa scope milik request yang membuatnya.
+func sharedValue(fnName string, v interface{}) interface{} {
+	switch t := v.(type) {
+	case nil, bool, float64, string:
+		return v
+	case map[string]interface{}:
+		for _, item := range t {
+			sharedValue(fnName, item)
+		}
+		return deepCopy(t)
+	case []interface{}:
+		for _, item := range t {
+			sharedValue(fnName, item)
+		}
+		return deepCopy(t)
+	}
+	panic(fmt.Sprintf("%s(): nilai %s tidak bisa dibagi antar request", fnName, typeOf(v)))
+}
+
+func sharedKeyLock(key string) *sync.Mutex {
+	sharedStore.mu.Lock()
+	defer sharedStore.mu.Unlock()
+	l, ok := sharedStore.locks[key]
+	if !ok {
+		l = &sync.Mutex{}
+		sharedStore.locks[key] = l
+	}
+	return l
+}
+
+func sharedLoad(key string) (interface{}, bool) {
+	sharedStore.mu.Lock()
+	defer sharedStore.mu.Unlock()
+	v, ok := sharedStore.values[key]
+	return deepCopy(v), ok
+}
+
+func sharedStoreValue(key string, v interface{}) {
+	sharedStore.mu.Lock()
+	sharedStore.values[key] = v
+	sharedStore.mu.Unlock()
+}
+
+// builtinSharedGet: shared_get("hits", 0).
+func builtinSharedGet(env *Env, args []interface{}) interface{} {
+	if v, ok := sharedLoad(args[0].(string)); ok {
+		return v
+	}
+	if len(args) > 1 {
+		return args[1]
+	}
+	return nil
+}
+
+func builtinSharedSet(env *Env, args []interface{}) interface{} {
+	sharedStoreValue(args[0].(string), sharedValue("shared_set", args[1]))
+	return true
+}
+
+func builtinSharedHas(env *Env, args []interface{}) interface{} {
+	_, ok := sharedLoad(args[0].(string))
+	return ok
+}
+
+func builtinSharedDelete(env *Env, args []interface{}) interface{} {
+	sharedStore.mu.Lock()
+	defer sharedStore.mu.Unlock()
+	_, ok := sharedStore.values[args[0].(string)]
+	delete(sharedStore.values, args[0].(string))
+	return ok
+}
+
+// builtinSharedIncr: shared_incr("hits") menambah counter secara atomik dan
+// mengembalikan nilai barunya. Key yang belum ada dianggap 0.
+func builtinSharedIncr(env *Env, args []interface{}) interface{} {
+	by := 1.0
+	if len(args) > 1 {
+		by = toNumber(args[1])
+	}
+	key := args[0].(string)
+	sharedStore.mu.Lock()
+	defer sharedStore.mu.Unlock()
+	n := toNumber(sharedStore.values[key]) + by
+	sharedStore.values[key] = n
+	return n
+}
+
+// builtinSharedUpdate: shared_update("visitors", fn($list) => ...) mengganti
+// nilai dengan hasil callback. Update ke key yang sama dijalankan satu per
+// satu, jadi tidak ada perubahan yang hilang.
+func builtinSharedUpdate(env *Env, args []interface{}) interface{} {
+	key := args[0].(string)
+	l := sharedKeyLock(key)
+	l.Lock()
+	defer l.Unlock()
+	old, _ := sharedLoad(key)
+	val := sharedValue("shared_update", callValue(args[1], []interface{}{old}, env))
+	sharedStoreValue(key, val)
+	return deepCopy(val)
+}
+
+// builtinSharedLock: shared_lock("stok", fn() => ...) menjalankan callback
+// sementara tidak ada shared_lock/shared_update lain dengan key yang sama.
+// Jangan memanggil shared_lock/shared_update dengan key yang sama dari
+// dalam callback, karena akan menunggu dirinya sendiri.
+func builtinSharedLock(env *Env, args []interface{}) interface{} {
+	l := sharedKeyLock(args[0].(string))
+	l.Lock()
+	defer l.Unlock()
+	return callValue(args[1], nil, env)
+}
@@ -0,0 +1,94 @@
+package monyet
+
+import (
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// Script ini dijalankan sekali, lalu handler-nya dipanggil dari banyak
+// goroutine sekaligus. Setiap request mengubah global, array global,
+// konstanta dan variabel closure; request lain tidak boleh melihatnya.
+const isolationScript = `
+$count = 0;
+$config = ["name" => "awal"];
+shared_set("isolasi_hits", 0);
+shared_set("isolasi_list", []);
+
+function hit() {
+    $count = $count + 1;
+    $config["name"] = $_GET["id"];
+    define("REQ_ID", $_GET["id"]);
+    shared_incr("isolasi_hits");
+    shared_update("isolasi_list", function($list) {
+        array_push($list, $_GET["id"]);
+        return $list;
+    });
+    return strval($count) + "|" + $config["name"] + "|" + REQ_ID;
+}
+
+function makeTick() {
+    $n = 0;
+    return function() {
+        $n = $n + 1;
+        return "n=" + $n;
+    };
+}
+$tick = makeTick();
+`
+
+func TestConcurrentRequestsAreIsolated(t *testing.T) {
+	env := NewEnv()
+	Eval(NewParser(NewLexer(isolationScript)).Parse(), env)
+	tick, _ := env.GetVar("tick")
+	routerOf(env) // seperti serve(): router sudah ada sebelum request pertama
+
+	const n = 50
+	var wg sync.WaitGroup
+	errs := make(chan string, 2*n)
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func(id string) {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			handleRequest(rec, httptest.NewRequest("GET", "/hit?id="+id, nil), env, "hit", nil, nil)
+			if want := "1|" + id + "|" + id; rec.Body.String() != want {
+				errs <- fmt.Sprintf("hit %s = %q, want %q", id, rec.Body.String(), want)
+			}
+		}(fmt.Sprint(i))
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			handleRequest(rec, httptest.NewRequest("GET", "/tick", nil), env, tick, nil, nil)
+			if body, _ := io.ReadAll(rec.Body); string(body) != "n=1" {
+				errs <- fmt.Sprintf("tick = %q, want n=1", body)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for e := range errs {
+		t.Error(e)
+	}
+
+	// scope global milik script tidak tersentuh oleh request
+	if v, _ := env.GetVar("count"); v != 0.0 {
+		t.Errorf("$count global = %v, want 0", v)
+	}
+	if v, _ := env.GetVar("config"); v.(map[string]interface{})["name"] != "awal" {
+		t.Errorf("$config global = %v", v)
+	}
+	if env.IsConst("REQ_ID") {
+		t.Error("define() di handler bocor ke scope global")
+	}
+
+	// shared_* memang dibagi, dan tidak ada update yang hilang
+	if v := evalExpr(t, `shared_get("isolasi_hits")`); v != float64(n) {
+		t.Errorf("shared_incr total = %v, want %d", v, n)
+	}
+	if v := evalExpr(t, `count(shared_get("isolasi_list"))`); v != float64(n) {
+		t.Errorf("shared_update menyimpan %v item, want %d", v, n)
+	}
+}
@@ -0,0 +1,76 @@
+package monyet
+
+import (
+	"log"
+	"sync"
+)
+
+func init() {
+	RegisterBuiltin(Builtin{Name: "on_shutdown", MinArgs: 1, MaxArgs: 1, Params: []string{"callable"}, Returns: "bool", Fn: builtinOnShutdown})
+}
+
+// shutdownHooks adalah callback on_shutdown() milik satu script, disimpan
+// di scope paling luar seperti __ROUTER__.
+type shutdownHooks struct {
+	mu   sync.Mutex
+	fns  []interface{}
+	done bool
+}
+
+func hooksOf(env *Env) *shutdownHooks {
+	root := env.root()
+	if v, ok := root.GetVar("__SHUTDOWN__"); ok {
+		if h, ok := v.(*shutdownHooks); ok {
+			return h
+		}
+	}
+	h := &shutdownHooks{}
+	root.SetVar("__SHUTDOWN__", h)
+	return h
+}
+
+// builtinOnShutdown: on_shutdown(fn() => ...) mendaftarkan callback yang
+// dijalankan sekali saat server berhenti (setelah request terakhir selesai)
+// atau saat script tanpa server selesai, sesuai urutan pendaftaran.
+func builtinOnShutdown(env *Env, args []interface{}) interface{} {
+	if env.req != nil {
+		panic("on_shutdown() tidak bisa dipanggil di dalam handler serve")
+	}
+	h := hooksOf(env)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fns = append(h.fns, args[0])
+	return true
+}
+
+// Shutdown menjalankan callback on_shutdown() lalu menutup semua database
+// yang terbuka. Aman dipanggil lebih dari sekali; callback hanya jalan sekali.
+func Shutdown(env *Env) {
+	h := hooksOf(env)
+	h.mu.Lock()
+	fns := h.fns
+	if h.done {
+		fns = nil
+	}
+	h.done = true
+	h.mu.Unlock()
+
+	defer closeStorages()
+	for _, fn := range fns {
+		runShutdownHook(fn, env)
+	}
+}
+
+// runShutdownHook menjalankan satu callback. Error di satu callback tidak
+// menghentikan yang lain; exit() diteruskan supaya kode exit-nya dipakai.
+func runShutdownHook(fn interface{}, env *Env) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(ExitSignal); ok {
+				panic(r)
+			}
+			log.Printf("on_shutdown: %v", r)
+		}
+	}()
+	callValue(fn, nil, env)
+}
@@ -0,0 +1,180 @@
+package monyet
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// logBuffer aman ditulis dari goroutine server sambil dibaca test.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// captureLog mengalihkan output package log (stderr) ke buffer.
+func captureLog(t *testing.T) *logBuffer {
+	t.Helper()
+	buf := &logBuffer{}
+	prevOut, prevFlags := log.Writer(), log.Flags()
+	log.SetOutput(buf)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(prevOut)
+		log.SetFlags(prevFlags)
+	})
+	return buf
+}
+
+func TestShutdownHooksRunInOrder(t *testing.T) {
+	logs := captureLog(t)
+	env, _ := fsEnv(t)
+	Eval(NewParser(NewLexer(`
+function hook_b() {
+    file_put_contents("urutan.txt", "b", FILE_APPEND);
+}
+on_shutdown(fn() => file_put_contents("urutan.txt", "a", FILE_APPEND));
+on_shutdown("hook_b");
+on_shutdown(fn() => tidak_ada());
+on_shutdown(function() {
+    file_put_contents("urutan.txt", "c", FILE_APPEND);
+});
+`)).Parse(), env)
+
+	base, _ := env.GetVar("__BASE_DIR__")
+	path := filepath.Join(base.(string), "urutan.txt")
+	if _, err := os.Stat(path); err == nil {
+		t.Fatal("callback jalan sebelum Shutdown")
+	}
+
+	Shutdown(env)
+	if b, _ := os.ReadFile(path); string(b) != "abc" {
+		t.Errorf("urutan callback = %q, want abc", b)
+	}
+	// callback yang error dicatat ke stderr, tidak menghentikan yang berikutnya
+	if !strings.Contains(logs.String(), "on_shutdown: ") || !strings.Contains(logs.String(), "tidak_ada") {
+		t.Errorf("log = %q", logs.String())
+	}
+
+	// Shutdown kedua tidak menjalankan callback lagi
+	Shutdown(env)
+	if b, _ := os.ReadFile(path); string(b) != "abc" {
+		t.Errorf("setelah Shutdown kedua = %q, want abc", b)
+	}
+}
+
+func TestShutdownHookExitStopsRest(t *testing.T) {
+	env := runScript(t, `
+on_shutdown(fn() => exit(3));
+on_shutdown(fn() => tidak_pernah());
+`)
+	defer func() {
+		r := recover()
+		if sig, ok := r.(ExitSignal); !ok || sig.Code != 3 {
+			t.Errorf("recover = %#v, want ExitSignal{3}", r)
+		}
+	}()
+	Shutdown(env)
+}
+
+func TestOnShutdownRejectedInHandler(t *testing.T) {
+	env := NewEnv()
+	env.req = &requestContext{}
+	if _, err := tryEval(env, `on_shutdown(fn() => 1)`); !strings.Contains(err, "di dalam handler") {
+		t.Errorf("error = %q", err)
+	}
+}
+
+// runServe menjalankan script serve dan mengembalikan kode ExitSignal-nya.
+func runServe(t *testing.T, env *Env, src string) (code int) {
+	t.Helper()
+	defer func() {
+		r := recover()
+		sig, ok := r.(ExitSignal)
+		if !ok {
+			t.Fatalf("serve berhenti dengan %#v, want ExitSignal", r)
+		}
+		code = sig.Code
+	}()
+	Eval(NewParser(NewLexer(src)).Parse(), env)
+	return -1
+}
+
+// busyPort membuka port di semua interface supaya serve() gagal bind.
+func busyPort(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	return fmt.Sprint(ln.Addr().(*net.TCPAddr).Port)
+}
+
+func freePort(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return fmt.Sprint(ln.Addr().(*net.TCPAddr).Port)
+}
+
+func TestServeBindFailureExitsNonZero(t *testing.T) {
+	logs := captureLog(t)
+	port := busyPort(t)
+	code := runServe(t, NewEnv(), `
+route("GET", "/", fn() => "halo");
+serve(`+port+`);
+`)
+	if code != 1 {
+		t.Errorf("exit code = %d, want 1", code)
+	}
+	if !strings.Contains(logs.String(), "Web Server Gagal") || !strings.Contains(logs.String(), port) {
+		t.Errorf("log = %q", logs.String())
+	}
+}
+
+func TestServeRedirectBindFailureExitsNonZero(t *testing.T) {
+	logs := captureLog(t)
+	dir := t.TempDir()
+	writeCert(t, dir)
+	env := NewEnv()
+	env.SetVar("__BASE_DIR__", dir)
+
+	port, redirect := freePort(t), busyPort(t)
+	code := runServe(t, env, `
+route("GET", "/", fn() => "halo");
+serve_tls(`+port+`, null, "cert.pem", "key.pem", ["redirect_http" => `+redirect+`]);
+`)
+	if code != 1 {
+		t.Errorf("exit code = %d, want 1", code)
+	}
+	if !strings.Contains(logs.String(), redirect) {
+		t.Errorf("log = %q", logs.String())
+	}
+	// listener HTTPS yang sempat terbuka ikut ditutup
+	if conn, err := net.Dial("tcp", "127.0.0.1:"+port); err == nil {
+		conn.Close()
+		t.Errorf("port %s masih menerima koneksi setelah serve gagal", port)
+	}
+}
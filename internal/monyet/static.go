@@ -0,0 +1,199 @@
+package monyet
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	RegisterBuiltin(Builtin{Name: "serve_static", MinArgs: 2, MaxArgs: 3, Params: []string{"string", "string", "array"}, Returns: "bool", Fn: builtinServeStatic})
+}
+
+// staticHandler melayani file dari satu folder tanpa lewat interpreter.
+// Folder dibuka sebagai os.Root, jadi path seperti ../ atau symlink yang
+// keluar dari folder tidak bisa dibaca.
+type staticHandler struct {
+	prefix        string
+	fsys          fs.FS
+	index         string
+	maxAge        int // -1 berarti tanpa Cache-Control
+	precompressed bool
+	dotfiles      bool
+}
+
+// builtinServeStatic: serve_static("/assets", "public/") melayani
+// public/app.js di /assets/app.js. Opsi:
+// - max_age: detik untuk Cache-Control (default tanpa header)
+// - index: file untuk URL folder (default index.html, "" untuk mematikan)
+// - precompressed: pakai app.js.br / app.js.gz kalau ada (default true)
+// - dotfiles: izinkan file seperti .env (default false)
+func builtinServeStatic(env *Env, args []interface{}) interface{} {
+	prefix := "/" + strings.Trim(args[0].(string), "/")
+	root, name := fsPath(env, args[1].(string))
+	dir, err := root.OpenRoot(name)
+	if err != nil {
+		panic(fmt.Sprintf("serve_static(): folder %s tidak bisa dibuka: %v", args[1], err))
+	}
+
+	h := &staticHandler{fsys: dir.FS(), index: "index.html", maxAge: -1, precompressed: true}
+	if len(args) > 2 && args[2] != nil {
+		opts, ok := args[2].(map[string]interface{})
+		if !ok {
+			panic(fmt.Sprintf("serve_static(): opsi harus array, dapat %s", typeOf(args[2])))
+		}
+		if v, ok := opts["max_age"]; ok {
+			h.maxAge = toInt(v)
+		}
+		if v, ok := opts["index"]; ok {
+			h.index = argString(v)
+		}
+		if v, ok := opts["precompressed"]; ok {
+			h.precompressed = isTruthy(v)
+		}
+		h.dotfiles = isTruthy(opts["dotfiles"])
+	}
+
+	router := routerOf(env)
+	router.mu.Lock()
+	defer router.mu.Unlock()
+
+	full := ""
+	for _, p := range router.prefixes {
+		full += strings.TrimSuffix(p, "/")
+	}
+	full = strings.TrimSuffix(full+prefix, "/")
+	h.prefix = full
+
+	var chain []middleware
+	for _, mws := range router.groupMiddleware {
+		chain = append(chain, mws...)
+	}
+	// Pola "GET /assets/" juga melayani HEAD, dan /assets otomatis
+	// di-redirect ke /assets/ oleh ServeMux
+	pattern := "GET " + full + "/"
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				panic(fmt.Sprintf("serve_static(): %s: %v", full+"/", r))
+			}
+		}()
+		router.mux.Handle(pattern, wrapHTTP(chain, h))
+	}()
+	router.routes = append(router.routes, routeInfo{Method: "GET", Path: full + "/", Handler: "static:" + args[1].(string)})
+	return true
+}
+
+func (h *staticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(path.Clean("/"+strings.TrimPrefix(r.URL.Path, h.prefix)), "/")
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) || (!h.dotfiles && hasDotSegment(name)) {
+		http.NotFound(w, r)
+		return
+	}
+
+	info, err := fs.Stat(h.fsys, name)
+	if err == nil && info.IsDir() {
+		if h.index == "" {
+			http.NotFound(w, r)
+			return
+		}
+		name = path.Join(name, h.index)
+		info, err = fs.Stat(h.fsys, name)
+	}
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	hdr := w.Header()
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = sniffContentType(h.fsys, name)
+	}
+	hdr.Set("Content-Type", contentType)
+	if h.maxAge >= 0 {
+		hdr.Set("Cache-Control", "public, max-age="+strconv.Itoa(h.maxAge))
+	}
+
+	// File .br / .gz hanya dipakai kalau lebih baru dari aslinya, supaya
+	// versi basi tidak terkirim setelah file asli diubah
+	serveName, encoding := name, ""
+	if h.precompressed {
+		hdr.Add("Vary", "Accept-Encoding")
+		accept := r.Header.Get("Accept-Encoding")
+		for _, enc := range []struct{ name, ext string }{{"br", ".br"}, {"gzip", ".gz"}} {
+			if !acceptsEncoding(accept, enc.name) {
+				continue
+			}
+			if ci, err := fs.Stat(h.fsys, name+enc.ext); err == nil && !ci.IsDir() && !ci.ModTime().Before(info.ModTime()) {
+				serveName, encoding, info = name+enc.ext, enc.name, ci
+				break
+			}
+		}
+	}
+
+	f, err := h.fsys.Open(serveName)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	rs, ok := f.(io.ReadSeeker)
+	if !ok {
+		http.Error(w, "file tidak bisa dibaca", http.StatusInternalServerError)
+		return
+	}
+
+	etag := fmt.Sprintf(`"%x-%x`, info.ModTime().UnixNano(), info.Size())
+	if encoding != "" {
+		hdr.Set("Content-Encoding", encoding)
+		etag += "-" + encoding
+	}
+	hdr.Set("ETag", etag+`"`)
+	// ServeContent yang mengurus If-None-Match/If-Modified-Since (304),
+	// Range (206) dan HEAD
+	http.ServeContent(w, r, name, info.ModTime(), rs)
+}
+
+func hasDotSegment(name string) bool {
+	for _, seg := range strings.Split(name, "/") {
+		if strings.HasPrefix(seg, ".") && seg != "." {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsEncoding mengecek Accept-Encoding, termasuk yang ditolak lewat q=0.
+func acceptsEncoding(header, enc string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), enc) {
+			continue
+		}
+		q := strings.ReplaceAll(params, " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+	return false
+}
+
+// sniffContentType menebak tipe dari 512 byte pertama untuk file tanpa
+// ekstensi yang dikenal.
+func sniffContentType(fsys fs.FS, name string) string {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	defer f.Close()
+	buf := make([]byte, 512)
+	n, _ := io.ReadFull(f, buf)
+	return http.DetectContentType(buf[:n])
+}
@@ -0,0 +1,191 @@
+package monyet
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// staticServer menyiapkan folder public/ di root script dan mengembalikan
+// handler router setelah script dijalankan.
+func staticServer(t *testing.T, src string) http.Handler {
+	t.Helper()
+	dir := t.TempDir()
+	app := filepath.Join(dir, "app")
+	pub := filepath.Join(app, "public")
+	os.MkdirAll(filepath.Join(pub, "docs"), 0755)
+	os.MkdirAll(filepath.Join(pub, ".git"), 0755)
+	files := map[string]string{
+		"app.js":         "console.log(1)",
+		"app.js.gz":      "GZIP",
+		"app.js.br":      "BROTLI",
+		"style.css":      "body{}",
+		"style.css.br":   "BROTLI CSS",
+		"index.html":     "<h1>home</h1>",
+		"docs/intro.txt": "intro",
+		".env":           "SECRET=1",
+		".git/config":    "[core]",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(pub, name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("RAHASIA"), 0644)
+	os.WriteFile(filepath.Join(app, "config.txt"), []byte("RAHASIA"), 0644)
+	if err := os.Symlink(filepath.Join(dir, "secret.txt"), filepath.Join(pub, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	// app.js.br lebih tua dari app.js (basi), app.js.gz lebih baru
+	now := time.Now()
+	os.Chtimes(filepath.Join(pub, "app.js.br"), now.Add(-time.Hour), now.Add(-time.Hour))
+	os.Chtimes(filepath.Join(pub, "app.js"), now, now)
+	os.Chtimes(filepath.Join(pub, "app.js.gz"), now.Add(time.Minute), now.Add(time.Minute))
+	os.Chtimes(filepath.Join(pub, "style.css"), now, now)
+	os.Chtimes(filepath.Join(pub, "style.css.br"), now.Add(time.Minute), now.Add(time.Minute))
+
+	env := NewEnv()
+	env.SetVar("__BASE_DIR__", app)
+	Eval(NewParser(NewLexer(src)).Parse(), env)
+	router := routerOf(env)
+	return wrapHTTP(router.middleware, router.mux)
+}
+
+func staticGet(h http.Handler, path string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestStaticFiles(t *testing.T) {
+	h := staticServer(t, `serve_static("/assets", "public/", ["max_age" => 60]);`)
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/assets/app.js", 200, "console.log(1)"},
+		{"/assets/", 200, "<h1>home</h1>"},
+		{"/assets/docs/intro.txt", 200, "intro"},
+		{"/assets/docs/", 404, ""},
+		{"/assets/tidak-ada.js", 404, ""},
+		{"/assets", http.StatusTemporaryRedirect, ""},
+	}
+	for _, tt := range tests {
+		rec := staticGet(h, tt.path, nil)
+		if rec.Code != tt.code || (tt.body != "" && rec.Body.String() != tt.body) {
+			t.Errorf("GET %s = %d %q, want %d %q", tt.path, rec.Code, rec.Body.String(), tt.code, tt.body)
+		}
+	}
+
+	rec := staticGet(h, "/assets/app.js", nil)
+	if ct := rec.Header().Get("Content-Type"); !strings.Contains(ct, "javascript") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "public, max-age=60" {
+		t.Errorf("Cache-Control = %q", cc)
+	}
+}
+
+func TestStaticRejectsTraversalAndDotfiles(t *testing.T) {
+	h := staticServer(t, `
+serve_static("/assets", "public/");
+serve_static("/all", "public/", ["dotfiles" => true]);
+`)
+	for _, path := range []string{
+		"/assets/../config.txt",
+		"/assets/..%2fconfig.txt",
+		"/assets/%2e%2e/%2e%2e/secret.txt",
+		"/assets/link.txt",
+		"/assets/.env",
+		"/assets/.git/config",
+		"/assets/docs/../.env",
+		"/all/link.txt",
+		"/all/..%2f..%2fsecret.txt",
+	} {
+		rec := staticGet(h, path, nil)
+		if rec.Code == 200 || strings.Contains(rec.Body.String(), "RAHASIA") || strings.Contains(rec.Body.String(), "SECRET") {
+			t.Errorf("GET %s = %d %q, harus ditolak", path, rec.Code, rec.Body.String())
+		}
+	}
+	// dotfiles => true membuka file titik, tapi tetap di dalam folder
+	if rec := staticGet(h, "/all/.env", nil); rec.Code != 200 || rec.Body.String() != "SECRET=1" {
+		t.Errorf("GET /all/.env = %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestStaticConditionalAndRange(t *testing.T) {
+	h := staticServer(t, `serve_static("/assets", "public/", ["precompressed" => false]);`)
+	first := staticGet(h, "/assets/app.js", nil)
+	etag := first.Header().Get("ETag")
+	if etag == "" || first.Header().Get("Last-Modified") == "" {
+		t.Fatalf("ETag/Last-Modified kosong: %v", first.Header())
+	}
+
+	if rec := staticGet(h, "/assets/app.js", map[string]string{"If-None-Match": etag}); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("If-None-Match = %d %q, want 304", rec.Code, rec.Body.String())
+	}
+	if rec := staticGet(h, "/assets/app.js", map[string]string{"If-None-Match": `"lain"`}); rec.Code != 200 {
+		t.Errorf("If-None-Match lain = %d, want 200", rec.Code)
+	}
+	lm := first.Header().Get("Last-Modified")
+	if rec := staticGet(h, "/assets/app.js", map[string]string{"If-Modified-Since": lm}); rec.Code != http.StatusNotModified {
+		t.Errorf("If-Modified-Since = %d, want 304", rec.Code)
+	}
+
+	rec := staticGet(h, "/assets/app.js", map[string]string{"Range": "bytes=0-6"})
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "console" {
+		t.Errorf("Range = %d %q, want 206 console", rec.Code, rec.Body.String())
+	}
+	if cr := rec.Header().Get("Content-Range"); cr != "bytes 0-6/14" {
+		t.Errorf("Content-Range = %q", cr)
+	}
+	if rec := staticGet(h, "/assets/app.js", map[string]string{"Range": "bytes=100-200"}); rec.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("Range di luar file = %d, want 416", rec.Code)
+	}
+}
+
+func TestStaticPrecompressed(t *testing.T) {
+	h := staticServer(t, `
+serve_static("/assets", "public/");
+serve_static("/raw", "public/", ["precompressed" => false]);
+`)
+	tests := []struct {
+		path, accept   string
+		body, encoding string
+	}{
+		// app.js.br basi (lebih tua dari app.js), jadi .gz yang dipakai
+		{"/assets/app.js", "br, gzip", "GZIP", "gzip"},
+		{"/assets/app.js", "gzip;q=0, br", "console.log(1)", ""},
+		{"/assets/app.js", "", "console.log(1)", ""},
+		{"/assets/style.css", "gzip, br", "BROTLI CSS", "br"},
+		{"/assets/style.css", "br;q=0", "body{}", ""},
+		{"/raw/app.js", "br, gzip", "console.log(1)", ""},
+	}
+	etags := map[string]string{}
+	for _, tt := range tests {
+		rec := staticGet(h, tt.path, map[string]string{"Accept-Encoding": tt.accept})
+		if rec.Code != 200 || rec.Body.String() != tt.body || rec.Header().Get("Content-Encoding") != tt.encoding {
+			t.Errorf("GET %s (%s) = %d %q encoding %q, want %q %q", tt.path, tt.accept, rec.Code, rec.Body.String(), rec.Header().Get("Content-Encoding"), tt.body, tt.encoding)
+		}
+		if ct := rec.Header().Get("Content-Type"); tt.encoding != "" && !strings.Contains(ct, "javascript") && !strings.Contains(ct, "css") {
+			t.Errorf("Content-Type %s = %q, harus mengikuti file asli", tt.path, ct)
+		}
+		etags[tt.path+tt.encoding] = rec.Header().Get("ETag")
+	}
+	if etags["/assets/app.jsgzip"] == etags["/assets/app.js"] {
+		t.Error("ETag versi gzip sama dengan versi asli")
+	}
+	if v := staticGet(h, "/assets/app.js", nil).Header().Get("Vary"); v != "Accept-Encoding" {
+		t.Errorf("Vary = %q", v)
+	}
+}
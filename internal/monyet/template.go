@@ -0,0 +1,570 @@
+package monyet
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Template engine untuk render(). Ekspresi di dalam tag adalah ekspresi
+// MonyetLang biasa, di-parse dengan parser yang sama seperti script:
+//
+//	{{ $user["name"] }}          output, di-escape HTML
+//	{!! $html !!}                output mentah
+//	{# komentar #}
+//	{% if $x > 1 %} {% elseif $y %} {% else %} {% endif %}
+//	{% foreach $items as $k => $v %} {% else %} {% endforeach %}
+//	{% include "partials/nav.html" %}
+//	{% extends "layout.html" %} {% block konten %}...{% endblock %}
+//	{% raw %}{{ tidak diproses }}{% endraw %}
+
+func init() {
+	RegisterBuiltin(Builtin{Name: "render", MinArgs: 1, MaxArgs: 2, Params: []string{"string", "array"}, Returns: "string", Fn: builtinRender})
+}
+
+type tplNode interface{}
+
+type tplText string
+
+type tplOutput struct {
+	Expr Node
+	Raw  bool
+	Line int
+}
+
+type tplBranch struct {
+	Cond Node // nil untuk else
+	Body []tplNode
+	Line int
+}
+
+type tplIf struct {
+	Branches []tplBranch
+}
+
+type tplForeach struct {
+	Iterable Node
+	Key      string
+	Value    string
+	Body     []tplNode
+	Else     []tplNode // dipakai kalau iterable kosong
+	Line     int
+}
+
+type tplInclude struct {
+	Path Node
+	Line int
+}
+
+type tplExtends struct {
+	Path Node
+	Line int
+}
+
+type tplBlock struct {
+	Name string
+	Body []tplNode
+}
+
+// Template adalah hasil kompilasi satu file template.
+type Template struct {
+	Name    string
+	Extends Node // nil kalau tidak memakai layout
+	Body    []tplNode
+	Blocks  map[string][]tplNode
+}
+
+type cachedTemplate struct {
+	modTime time.Time
+	size    int64
+	tpl     *Template
+}
+
+// templateCache menyimpan template yang sudah dikompilasi per path. File
+// dikompilasi ulang hanya kalau waktu ubah atau ukurannya berubah.
+var templateCache sync.Map
+
+// loadTemplate mengambil template dari cache atau mengompilasinya. File
+// dibaca lewat os.Root, jadi symlink yang keluar dari folder template juga
+// ditolak, sama seperti fungsi filesystem.
+func loadTemplate(root *os.Root, name string) (*Template, error) {
+	info, err := root.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(root.Name(), name)
+	if c, ok := templateCache.Load(path); ok {
+		ct := c.(*cachedTemplate)
+		if ct.modTime.Equal(info.ModTime()) && ct.size == info.Size() {
+			return ct.tpl, nil
+		}
+	}
+	src, err := root.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	tpl := compileTemplate(filepath.Base(name), string(src))
+	templateCache.Store(path, &cachedTemplate{modTime: info.ModTime(), size: info.Size(), tpl: tpl})
+	return tpl, nil
+}
+
+// templateName mengubah nama template menjadi path relatif terhadap folder
+// template. Nama yang keluar dari folder itu ("../x", path absolut di luar
+// folder) ditolak.
+func templateName(dir, name string) string {
+	clean := name
+	if filepath.IsAbs(name) {
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			panic(fmt.Sprintf("render: akses ditolak: %s di luar folder template", name))
+		}
+		clean = rel
+	}
+	clean = filepath.Clean(clean)
+	if !filepath.IsLocal(clean) {
+		panic(fmt.Sprintf("render: akses ditolak: %s di luar folder template", name))
+	}
+	return clean
+}
+
+// tplToken adalah potongan template: teks biasa atau isi satu tag.
+type tplToken struct {
+	kind string // "text", "output", "raw", "tag"
+	val  string
+	line int
+}
+
+// lexTemplate memecah template menjadi teks dan tag. Tag {% %} atau
+// komentar yang sendirian di satu baris dibuang bersama indentasi dan
+// newline-nya, supaya tidak meninggalkan baris kosong di output.
+func lexTemplate(name, src string) []tplToken {
+	var toks []tplToken
+	line := 1
+	atLineStart := true
+	for len(src) > 0 {
+		i := strings.IndexByte(src, '{')
+		if i < 0 || i+1 >= len(src) {
+			toks = append(toks, tplToken{kind: "text", val: src, line: line})
+			break
+		}
+
+		var open, close, kind string
+		switch {
+		case strings.HasPrefix(src[i:], "{{"):
+			open, close, kind = "{{", "}}", "output"
+		case strings.HasPrefix(src[i:], "{!!"):
+			open, close, kind = "{!!", "!!}", "raw"
+		case strings.HasPrefix(src[i:], "{%"):
+			open, close, kind = "{%", "%}", "tag"
+		case strings.HasPrefix(src[i:], "{#"):
+			open, close, kind = "{#", "#}", "comment"
+		default:
+			toks = append(toks, tplToken{kind: "text", val: src[:i+1], line: line})
+			line += strings.Count(src[:i+1], "\n")
+			atLineStart = false
+			src = src[i+1:]
+			continue
+		}
+
+		rest := src[i+len(open):]
+		end := strings.Index(rest, close)
+		if end < 0 {
+			panic(fmt.Sprintf("render: %s baris %d: %s tidak ditutup dengan %s", name, line+strings.Count(src[:i], "\n"), open, close))
+		}
+		body := rest[:end]
+		after := rest[end+len(close):]
+
+		text := src[:i]
+		nl := strings.LastIndexByte(text, '\n')
+		indent := text[nl+1:]
+		standalone := false
+		if kind == "tag" || kind == "comment" {
+			startsLine := nl >= 0 || atLineStart
+			tail := strings.TrimLeft(after, " \t")
+			endsLine := tail == "" || strings.HasPrefix(tail, "\n") || strings.HasPrefix(tail, "\r\n")
+			standalone = startsLine && strings.TrimLeft(indent, " \t") == "" && endsLine
+		}
+		if standalone {
+			text = text[:nl+1]
+		}
+		if text != "" {
+			toks = append(toks, tplToken{kind: "text", val: text, line: line})
+		}
+		line += strings.Count(src[:i], "\n")
+		tagLine := line
+		line += strings.Count(body, "\n")
+		atLineStart = false
+		if standalone {
+			after = strings.TrimLeft(after, " \t")
+			after = strings.TrimPrefix(strings.TrimPrefix(after, "\r"), "\n")
+			line++
+			atLineStart = true
+		}
+		src = after
+
+		if kind == "tag" && strings.TrimSpace(body) == "raw" {
+			// {% raw %} ... {% endraw %} dikeluarkan apa adanya
+			endRaw := strings.Index(src, "{% endraw %}")
+			if endRaw < 0 {
+				panic(fmt.Sprintf("render: %s baris %d: raw tidak ditutup dengan endraw", name, tagLine))
+			}
+			toks = append(toks, tplToken{kind: "text", val: src[:endRaw], line: line})
+			line += strings.Count(src[:endRaw], "\n")
+			src = src[endRaw+len("{% endraw %}"):]
+			atLineStart = false
+			continue
+		}
+		if kind != "comment" {
+			toks = append(toks, tplToken{kind: kind, val: strings.TrimSpace(body), line: tagLine})
+		}
+	}
+	return toks
+}
+
+// tplParser menyusun token template menjadi pohon node.
+type tplParser struct {
+	name   string
+	toks   []tplToken
+	pos    int
+	blocks map[string][]tplNode
+}
+
+func compileTemplate(name, src string) *Template {
+	p := &tplParser{name: name, toks: lexTemplate(name, src), blocks: map[string][]tplNode{}}
+	tpl := &Template{Name: name, Blocks: p.blocks}
+
+	body, end := p.parseNodes()
+	if end != "" {
+		p.errorf(p.toks[p.pos-1].line, "{%% %s %%} tanpa pembuka", end)
+	}
+	// {% extends %} harus tag pertama (boleh didahului spasi)
+	for i, n := range body {
+		if t, ok := n.(tplText); ok && strings.TrimSpace(string(t)) == "" {
+			continue
+		}
+		if ext, ok := n.(tplExtends); ok {
+			tpl.Extends = ext.Path
+			body = body[i+1:]
+		}
+		break
+	}
+	tpl.Body = body
+	return tpl
+}
+
+func (p *tplParser) errorf(line int, format string, args ...interface{}) {
+	panic(fmt.Sprintf("render: %s baris %d: %s", p.name, line, fmt.Sprintf(format, args...)))
+}
+
+// parseNodes membaca node sampai token habis atau bertemu tag penutup
+// (endif, else, endforeach, ...). Nama tag penutup dikembalikan.
+func (p *tplParser) parseNodes() ([]tplNode, string) {
+	var nodes []tplNode
+	for p.pos < len(p.toks) {
+		tok := p.toks[p.pos]
+		p.pos++
+		switch tok.kind {
+		case "text":
+			nodes = append(nodes, tplText(tok.val))
+		case "output", "raw":
+			nodes = append(nodes, tplOutput{Expr: p.expr(tok.val, tok.line), Raw: tok.kind == "raw", Line: tok.line})
+		case "tag":
+			word, rest, _ := strings.Cut(tok.val, " ")
+			rest = strings.TrimSpace(rest)
+			switch word {
+			case "if":
+				nodes = append(nodes, p.parseIf(rest, tok.line))
+			case "foreach":
+				nodes = append(nodes, p.parseForeach(rest, tok.line))
+			case "include":
+				nodes = append(nodes, tplInclude{Path: p.expr(rest, tok.line), Line: tok.line})
+			case "extends":
+				nodes = append(nodes, tplExtends{Path: p.expr(rest, tok.line), Line: tok.line})
+			case "block":
+				nodes = append(nodes, p.parseBlock(rest, tok.line))
+			case "endif", "elseif", "else", "endforeach", "endblock":
+				return nodes, tok.val
+			default:
+				p.errorf(tok.line, "tag {%% %s %%} tidak dikenal", word)
+			}
+		}
+	}
+	return nodes, ""
+}
+
+func (p *tplParser) parseIf(cond string, line int) tplIf {
+	var n tplIf
+	branch := tplBranch{Cond: p.expr(cond, line), Line: line}
+	for {
+		body, end := p.parseNodes()
+		branch.Body = body
+		n.Branches = append(n.Branches, branch)
+
+		endLine := p.toks[p.pos-1].line
+		word, rest, _ := strings.Cut(end, " ")
+		isElseIf := word == "elseif" || (word == "else" && strings.HasPrefix(rest, "if "))
+		switch {
+		case end == "endif":
+			return n
+		case isElseIf && branch.Cond != nil:
+			cond := strings.TrimPrefix(strings.TrimSpace(rest), "if ")
+			branch = tplBranch{Cond: p.expr(cond, endLine), Line: endLine}
+		case end == "else" && branch.Cond != nil:
+			branch = tplBranch{Line: endLine}
+		default:
+			p.errorf(line, "if tidak ditutup dengan endif")
+		}
+	}
+}
+
+// parseForeach: "$items as $item" atau "$items as $key => $item".
+func (p *tplParser) parseForeach(spec string, line int) tplForeach {
+	idx := strings.LastIndex(spec, " as ")
+	if idx < 0 {
+		p.errorf(line, "foreach harus berbentuk {%% foreach $list as $item %%}")
+	}
+	n := tplForeach{Iterable: p.expr(spec[:idx], line), Line: line}
+	vars := strings.TrimSpace(spec[idx+4:])
+	if k, v, ok := strings.Cut(vars, "=>"); ok {
+		n.Key, n.Value = p.varName(k, line), p.varName(v, line)
+	} else {
+		n.Value = p.varName(vars, line)
+	}
+
+	body, end := p.parseNodes()
+	n.Body = body
+	if end == "else" {
+		n.Else, end = p.parseNodes()
+	}
+	if end != "endforeach" {
+		p.errorf(line, "foreach tidak ditutup dengan endforeach")
+	}
+	return n
+}
+
+func (p *tplParser) parseBlock(name string, line int) tplBlock {
+	if name == "" {
+		p.errorf(line, "block harus punya nama")
+	}
+	if _, dup := p.blocks[name]; dup {
+		p.errorf(line, "block %s didefinisikan dua kali", name)
+	}
+	body, end := p.parseNodes()
+	if end != "endblock" && end != "endblock "+name {
+		p.errorf(line, "block %s tidak ditutup dengan endblock", name)
+	}
+	p.blocks[name] = body
+	return tplBlock{Name: name, Body: body}
+}
+
+func (p *tplParser) varName(s string, line int) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "$") || len(s) < 2 {
+		p.errorf(line, "%q bukan nama variabel", s)
+	}
+	return s[1:]
+}
+
+// expr mem-parse satu ekspresi MonyetLang dari isi tag.
+func (p *tplParser) expr(src string, line int) (n Node) {
+	if strings.TrimSpace(src) == "" {
+		p.errorf(line, "ekspresi kosong")
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			p.errorf(line, "%v", r)
+		}
+	}()
+	ep := NewParser(NewLexer(src))
+	n = ep.parseExpr()
+	if ep.cur.Type != EOF {
+		panic(fmt.Sprintf("token %q tidak terduga di %q", ep.cur.Value, src))
+	}
+	return n
+}
+
+// tplRenderer menyimpan state satu kali render: block yang berlaku dan
+// template yang sedang di-include (untuk mendeteksi include berulang).
+type tplRenderer struct {
+	root   *os.Root
+	blocks map[string][]tplNode
+	stack  []string
+	out    strings.Builder
+}
+
+// renderFile merender template beserta layout-nya. Block dari template
+// anak menimpa block dengan nama yang sama di layout.
+func (r *tplRenderer) renderFile(name string, env *Env) {
+	path := templateName(r.root.Name(), name)
+	for _, p := range r.stack {
+		if p == path {
+			panic(fmt.Sprintf("render: %s meng-include dirinya sendiri", name))
+		}
+	}
+	tpl, err := loadTemplate(r.root, path)
+	if err != nil {
+		panic(fmt.Sprintf("render: template %s tidak ditemukan", name))
+	}
+	r.stack = append(r.stack, path)
+	defer func() { r.stack = r.stack[:len(r.stack)-1] }()
+
+	for block, body := range tpl.Blocks {
+		if _, overridden := r.blocks[block]; !overridden {
+			r.blocks[block] = body
+		}
+	}
+	if tpl.Extends != nil {
+		r.renderFile(argString(r.eval(tpl, tpl.Extends, 0, env)), env)
+		return
+	}
+	r.renderNodes(tpl, tpl.Body, env)
+}
+
+func (r *tplRenderer) renderNodes(tpl *Template, nodes []tplNode, env *Env) {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case tplText:
+			r.out.WriteString(string(n))
+		case tplOutput:
+			s := templateString(r.eval(tpl, n.Expr, n.Line, env))
+			if !n.Raw {
+				s = html.EscapeString(s)
+			}
+			r.out.WriteString(s)
+		case tplIf:
+			for _, b := range n.Branches {
+				if b.Cond == nil || isTruthy(r.eval(tpl, b.Cond, b.Line, env)) {
+					r.renderNodes(tpl, b.Body, env)
+					break
+				}
+			}
+		case tplForeach:
+			r.renderForeach(tpl, n, env)
+		case tplInclude:
+			r.renderFile(argString(r.eval(tpl, n.Path, n.Line, env)), env)
+		case tplExtends:
+			panic(fmt.Sprintf("render: %s baris %d: extends harus ditulis paling awal", tpl.Name, n.Line))
+		case tplBlock:
+			body, ok := r.blocks[n.Name]
+			if !ok {
+				body = n.Body
+			}
+			r.renderNodes(tpl, body, env)
+		}
+	}
+}
+
+func (r *tplRenderer) renderForeach(tpl *Template, n tplForeach, env *Env) {
+	iter := r.eval(tpl, n.Iterable, n.Line, env)
+	empty := true
+	each := func(k, v interface{}) {
+		empty = false
+		local := NewChildEnv(env)
+		if n.Key != "" {
+			local.SetVar(n.Key, k)
+		}
+		local.SetVar(n.Value, v)
+		r.renderNodes(tpl, n.Body, local)
+	}
+
+	switch it := iter.(type) {
+	case *Generator:
+		defer it.Close()
+		for {
+			k, v, ok := it.Next()
+			if !ok {
+				break
+			}
+			each(k, v)
+		}
+	case []interface{}:
+		for i, v := range it {
+			each(float64(i), v)
+		}
+	case map[string]interface{}:
+		for _, k := range orderedKeys(it) {
+			each(k, it[k])
+		}
+	case nil:
+	default:
+		panic(fmt.Sprintf("render: %s baris %d: foreach butuh array, dapat %s", tpl.Name, n.Line, typeOf(iter)))
+	}
+	if empty {
+		r.renderNodes(tpl, n.Else, env)
+	}
+}
+
+// eval mengevaluasi ekspresi template. Error diberi nama file dan baris
+// template, exit() dibiarkan lewat.
+func (r *tplRenderer) eval(tpl *Template, n Node, line int, env *Env) interface{} {
+	defer func() {
+		if rec := recover(); rec != nil {
+			if _, ok := rec.(ExitSignal); ok || strings.HasPrefix(fmt.Sprint(rec), "render: ") {
+				panic(rec)
+			}
+			panic(fmt.Sprintf("render: %s baris %d: %v", tpl.Name, line, rec))
+		}
+	}()
+	return evalNode(n, env)
+}
+
+// templateString mengubah nilai menjadi teks seperti echo di PHP: null dan
+// false jadi kosong, true jadi "1". Array dikeluarkan sebagai JSON supaya
+// bisa langsung dipakai di <script> lewat {!! $data !!}.
+func templateString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case bool:
+		if t {
+			return "1"
+		}
+		return ""
+	case string:
+		return t
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(jsonValue(t))
+		if err != nil {
+			panic(fmt.Sprintf("render: gagal encode array: %v", err))
+		}
+		return string(b)
+	}
+	return formatValue(v)
+}
+
+// builtinRender: render("views/home.html", ["user" => $user]). Key array
+// data menjadi variabel di template. Path relatif terhadap folder script.
+func builtinRender(env *Env, args []interface{}) interface{} {
+	name := args[0].(string)
+	dir := baseDir(env)
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	root, err := openRoot(dir)
+	if err != nil {
+		panic(fmt.Sprintf("render: folder template %s tidak bisa dibuka: %v", dir, err))
+	}
+	if _, err := root.Stat(templateName(dir, name)); err != nil {
+		return fmt.Sprintf("Render Error: File %s tidak ditemukan", filepath.Join(dir, name))
+	}
+
+	local := NewChildEnv(env)
+	if len(args) > 1 && args[1] != nil {
+		data, ok := args[1].(map[string]interface{})
+		if !ok {
+			panic(fmt.Sprintf("render(): data harus array dengan key, dapat %s", typeOf(args[1])))
+		}
+		for k, v := range data {
+			local.SetVar(k, v)
+		}
+	}
+
+	r := &tplRenderer{root: root, blocks: map[string][]tplNode{}}
+	r.renderFile(name, local)
+	return r.out.String()
+}
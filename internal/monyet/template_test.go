@@ -0,0 +1,63 @@
+package monyet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// renderIn merender template di folder app dan mengembalikan output atau
+// pesan panic-nya.
+func renderIn(app, name string) (out string, err string) {
+	env := NewEnv()
+	env.SetVar("__BASE_DIR__", app)
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Sprint(r)
+		}
+	}()
+	return builtinRender(env, []interface{}{name, map[string]interface{}{"nama": "monyet"}}).(string), ""
+}
+
+func TestRenderConfinedToTemplateDir(t *testing.T) {
+	dir := t.TempDir()
+	app := filepath.Join(dir, "app")
+	files := map[string]string{
+		"secret.txt":              "RAHASIA",
+		"app/views/layout.html":   "<main>{% block isi %}{% endblock %}</main>",
+		"app/views/home.html":     `{% extends "views/layout.html" %}{% block isi %}halo {{ $nama }}{% include "views/footer.html" %}{% endblock %}`,
+		"app/views/footer.html":   " - footer",
+		"app/views/include.html":  `{% include "../secret.txt" %}`,
+		"app/views/extends.html":  `{% extends "../../secret.txt" %}`,
+		"app/views/absolute.html": `{% include "` + filepath.Join(dir, "secret.txt") + `" %}`,
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(dir, "secret.txt"), filepath.Join(app, "views", "link.html")); err != nil {
+		t.Fatal(err)
+	}
+
+	if out, err := renderIn(app, "views/home.html"); err != "" || out != "<main>halo monyet - footer</main>" {
+		t.Errorf("render home = %q, %q", out, err)
+	}
+
+	for _, name := range []string{"views/include.html", "views/extends.html", "views/absolute.html", "views/link.html", "../secret.txt"} {
+		out, err := renderIn(app, name)
+		if strings.Contains(out, "RAHASIA") || strings.Contains(err, "RAHASIA") {
+			t.Errorf("%s membocorkan file di luar folder template: %q", name, out)
+		}
+		if err == "" && !strings.HasPrefix(out, "Render Error") {
+			t.Errorf("%s seharusnya gagal, dapat %q", name, out)
+		}
+	}
+	if _, err := renderIn(app, "views/include.html"); !strings.Contains(err, "akses ditolak") {
+		t.Errorf("include ../ error = %q", err)
+	}
+}
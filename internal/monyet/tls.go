@@ -0,0 +1,157 @@
+package monyet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Seberapa sering file sertifikat dicek ulang. Pengecekan terjadi saat
+// handshake, jadi server yang diam tidak membaca disk sama sekali.
+const certCheckInterval = time.Second
+
+// certReloader memberi sertifikat ke tls.Config lewat GetCertificate dan
+// memuat ulang file cert/key kalau berubah (misalnya setelah diperpanjang
+// certbot), tanpa restart server. Kalau file baru gagal dimuat (cert sudah
+// diganti tapi key belum), sertifikat lama tetap dipakai.
+type certReloader struct {
+	certPath, keyPath string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	stamp   string
+	checked time.Time
+}
+
+func newCertReloader(certPath, keyPath string) (*certReloader, error) {
+	r := &certReloader{certPath: certPath, keyPath: keyPath}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// fileStamp merangkum waktu ubah dan ukuran kedua file.
+func (r *certReloader) fileStamp() (string, error) {
+	stamp := ""
+	for _, p := range []string{r.certPath, r.keyPath} {
+		info, err := os.Stat(p)
+		if err != nil {
+			return "", err
+		}
+		stamp += fmt.Sprintf("%d-%d;", info.ModTime().UnixNano(), info.Size())
+	}
+	return stamp, nil
+}
+
+func (r *certReloader) reload() error {
+	stamp, err := r.fileStamp()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certPath, r.keyPath)
+	if err != nil {
+		return err
+	}
+	r.cert, r.stamp = &cert, stamp
+	return nil
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checked) >= certCheckInterval {
+		r.checked = time.Now()
+		if stamp, err := r.fileStamp(); err == nil && stamp != r.stamp {
+			if err := r.reload(); err != nil {
+				log.Printf("TLS: sertifikat baru gagal dimuat, tetap memakai yang lama: %v", err)
+			} else {
+				log.Println("TLS: sertifikat dimuat ulang")
+			}
+		}
+	}
+	return r.cert, nil
+}
+
+// redirectHTTPS mengarahkan semua request HTTP ke alamat yang sama di port
+// HTTPS. GET/HEAD memakai 301, method lain 308 supaya body tidak hilang.
+func redirectHTTPS(tlsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.Trim(host, "[]")
+		if tlsPort != "443" {
+			host = net.JoinHostPort(host, tlsPort)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		code := http.StatusMovedPermanently
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			code = http.StatusPermanentRedirect
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), code)
+	})
+}
+
+// GenerateCert membuat sertifikat self-signed (ECDSA P-256) untuk
+// development. hosts boleh berisi nama domain maupun IP; yang pertama
+// dipakai sebagai Common Name. Sertifikat ini leaf biasa (bukan CA), jadi
+// hanya berlaku untuk host-host itu; percayai langsung lewat curl --cacert.
+func GenerateCert(hosts []string, validFor time.Duration) (certPEM, keyPEM []byte, err error) {
+	if len(hosts) == 0 {
+		return nil, nil, fmt.Errorf("minimal satu host")
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	tmpl := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"MonyetLang Development"}, CommonName: hosts[0]},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(validFor),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
@@ -0,0 +1,221 @@
+package monyet
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeCert membuat sertifikat development baru di dir dan mengembalikannya
+// dalam bentuk ter-parse untuk dicocokkan di sisi client.
+func writeCert(t *testing.T, dir string) *x509.Certificate {
+	t.Helper()
+	certPEM, keyPEM, err := GenerateCert([]string{"127.0.0.1", "localhost"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPath, keyPath := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf
+}
+
+// startTLS menjalankan server HTTPS di 127.0.0.1 dengan certReloader,
+// seperti serve_tls().
+func startTLS(t *testing.T, reloader *certReloader) string {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.getCertificate,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "halo "+r.URL.Path)
+		}),
+		ErrorLog: log.New(io.Discard, "", 0),
+	}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+	return ln.Addr().String()
+}
+
+// handshake membuka koneksi TLS dan mengembalikan sertifikat dari server.
+func handshake(t *testing.T, addr string, roots *x509.CertPool) *x509.Certificate {
+	t.Helper()
+	conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: roots})
+	if err != nil {
+		t.Fatalf("handshake gagal: %v", err)
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0]
+}
+
+func TestGenerateCertIsLeaf(t *testing.T) {
+	leaf := writeCert(t, t.TempDir())
+	if leaf.IsCA {
+		t.Error("sertifikat development tidak boleh CA")
+	}
+	if leaf.KeyUsage&x509.KeyUsageCertSign != 0 {
+		t.Error("sertifikat development tidak boleh punya KeyUsageCertSign")
+	}
+	if leaf.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+		t.Error("KeyUsageDigitalSignature hilang")
+	}
+	if len(leaf.ExtKeyUsage) != 1 || leaf.ExtKeyUsage[0] != x509.ExtKeyUsageServerAuth {
+		t.Errorf("ExtKeyUsage = %v", leaf.ExtKeyUsage)
+	}
+}
+
+func TestTLSHandshake(t *testing.T) {
+	dir := t.TempDir()
+	leaf := writeCert(t, dir)
+	reloader, err := newCertReloader(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := startTLS(t, reloader)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(leaf)
+	if got := handshake(t, addr, roots); got.SerialNumber.Cmp(leaf.SerialNumber) != 0 {
+		t.Errorf("serial = %v, want %v", got.SerialNumber, leaf.SerialNumber)
+	}
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	resp, err := client.Get("https://" + addr + "/dunia")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "halo /dunia" {
+		t.Errorf("body = %q", body)
+	}
+
+	// tanpa sertifikat di trust store, handshake harus ditolak
+	if conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: x509.NewCertPool()}); err == nil {
+		conn.Close()
+		t.Error("handshake dengan trust store kosong seharusnya gagal")
+	}
+}
+
+func TestRedirectHTTPS(t *testing.T) {
+	dir := t.TempDir()
+	leaf := writeCert(t, dir)
+	reloader, err := newCertReloader(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := startTLS(t, reloader)
+	_, tlsPort, _ := net.SplitHostPort(addr)
+
+	plain := httptest.NewServer(redirectHTTPS(tlsPort))
+	defer plain.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(leaf)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	resp, err := client.Get(plain.URL + "/a?b=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.TLS == nil || string(body) != "halo /a" {
+		t.Errorf("redirect tidak sampai ke HTTPS: tls=%v body=%q", resp.TLS != nil, body)
+	}
+
+	tests := []struct {
+		method string
+		host   string
+		port   string
+		code   int
+		want   string
+	}{
+		{"GET", "example.com:8080", "8443", 301, "https://example.com:8443/x?y=1"},
+		{"HEAD", "example.com", "443", 301, "https://example.com/x?y=1"},
+		{"POST", "example.com:80", "443", 308, "https://example.com/x?y=1"},
+		{"PUT", "[::1]:8080", "443", 308, "https://[::1]/x?y=1"},
+		{"GET", "[::1]:8080", "8443", 301, "https://[::1]:8443/x?y=1"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "http://"+tt.host+"/x?y=1", nil)
+		rec := httptest.NewRecorder()
+		redirectHTTPS(tt.port).ServeHTTP(rec, req)
+		if rec.Code != tt.code || rec.Header().Get("Location") != tt.want {
+			t.Errorf("%s %s -> %d %s, want %d %s", tt.method, tt.host, rec.Code, rec.Header().Get("Location"), tt.code, tt.want)
+		}
+	}
+}
+
+func TestCertHotReload(t *testing.T) {
+	logs := captureLog(t)
+	dir := t.TempDir()
+	old := writeCert(t, dir)
+	reloader, err := newCertReloader(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := startTLS(t, reloader)
+	roots := x509.NewCertPool()
+	roots.AddCert(old)
+	if got := handshake(t, addr, roots); got.SerialNumber.Cmp(old.SerialNumber) != 0 {
+		t.Fatal("sertifikat awal tidak dipakai")
+	}
+
+	// cert diperpanjang: file diganti, waktu ubah dimajukan supaya stamp
+	// pasti berbeda, dan jeda pengecekan dilewati
+	renewed := writeCert(t, dir)
+	future := time.Now().Add(time.Minute)
+	for _, name := range []string{"cert.pem", "key.pem"} {
+		os.Chtimes(filepath.Join(dir, name), future, future)
+	}
+	reloader.mu.Lock()
+	reloader.checked = time.Time{}
+	reloader.mu.Unlock()
+
+	roots.AddCert(renewed)
+	if got := handshake(t, addr, roots); got.SerialNumber.Cmp(renewed.SerialNumber) != 0 {
+		t.Errorf("serial = %v, want sertifikat baru %v", got.SerialNumber, renewed.SerialNumber)
+	}
+	// pesan reload masuk ke log (stderr), bukan stdout
+	if !strings.Contains(logs.String(), "TLS: sertifikat dimuat ulang") {
+		t.Errorf("log = %q", logs.String())
+	}
+
+	// file rusak: sertifikat terakhir yang valid tetap dipakai
+	os.WriteFile(filepath.Join(dir, "key.pem"), []byte("bukan key"), 0600)
+	reloader.mu.Lock()
+	reloader.checked = time.Time{}
+	reloader.mu.Unlock()
+	if got := handshake(t, addr, roots); got.SerialNumber.Cmp(renewed.SerialNumber) != 0 {
+		t.Errorf("setelah file rusak serial = %v, want tetap %v", got.SerialNumber, renewed.SerialNumber)
+	}
+	if !strings.Contains(logs.String(), "TLS: sertifikat baru gagal dimuat") {
+		t.Errorf("log = %q", logs.String())
+	}
+}
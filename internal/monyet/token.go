@@ -3,7 +3,6 @@ package monyet
 type TokenType string
 
 const (
-	RENDER  = "RENDER"
 	INCLUDE = "INCLUDE"
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
@@ -18,32 +17,33 @@ const (
 	SLASH  = "/"
 
 	SEMICOLON = ";"
+	COLON     = ":"
 	LPAREN    = "("
 	RPAREN    = ")"
 
 	DOLLAR = "$"
 
-	ECHO        = "ECHO"
-	STRING      = "STRING"
-	GT          = ">"
-	LBRACE      = "{"
-	RBRACE      = "}"
-	COMMA       = ","
-	IF          = "IF"
-	ELSE        = "ELSE"
-	EQ          = "=="
-	LBRACKET    = "["
-	RBRACKET    = "]"
-	FUNCTION    = "FUNCTION"
-	RETURN      = "RETURN"
-	SERVE       = "SERVE"
-	AND         = "&&"
-	OR          = "||"
-	JSON_ENCODE = "JSON_ENCODE"
-	JSON_DECODE = "JSON_DECODE"
-	ARROW       = "=>"
-	FOREACH     = "FOREACH"
-	AS          = "AS"
+	ECHO     = "ECHO"
+	STRING   = "STRING"
+	GT       = ">"
+	LBRACE   = "{"
+	RBRACE   = "}"
+	COMMA    = ","
+	IF       = "IF"
+	ELSE     = "ELSE"
+	EQ       = "=="
+	LBRACKET = "["
+	RBRACKET = "]"
+	FUNCTION = "FUNCTION"
+	RETURN   = "RETURN"
+	SERVE    = "SERVE"
+	AND      = "&&"
+	OR       = "||"
+	ARROW    = "=>"
+	FOREACH  = "FOREACH"
+	AS       = "AS"
+	CONST    = "CONST"
+	YIELD    = "YIELD"
 )
 
 type Token struct {
@@ -0,0 +1,82 @@
+package monyet
+
+import (
+	"fmt"
+	"math"
+)
+
+// Tipe yang boleh dipakai di anotasi. "void" hanya valid sebagai tipe return.
+var typeNames = map[string]bool{
+	"int":      true,
+	"float":    true,
+	"string":   true,
+	"bool":     true,
+	"array":    true,
+	"mixed":    true,
+	"iterable": true, // array atau generator
+	"callable": true, // closure atau nama function
+}
+
+func isValidType(t string, isReturn bool) bool {
+	if isReturn && t == "void" {
+		return true
+	}
+	return typeNames[t]
+}
+
+// typeOf mengembalikan nama tipe MonyetLang untuk nilai runtime.
+// Semua angka disimpan sebagai float64, jadi angka bulat dianggap int.
+func typeOf(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return "null"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "int"
+		}
+		return "float"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case map[string]interface{}, []interface{}:
+		return "array"
+	case *Generator:
+		return "generator"
+	case *Closure, *NativeFunc:
+		return "callable"
+	case *DateTime:
+		return "datetime"
+	case *FileHandle:
+		return "resource"
+	case *HTTPResponse:
+		return "response"
+	}
+	return fmt.Sprintf("%T", val)
+}
+
+// typeAccepts mengecek apakah tipe aktual boleh dipakai di tempat tipe
+// yang dideklarasikan. int selalu boleh masuk ke float. Tipe aktual ""
+// (tidak diketahui oleh checker statis) selalu dianggap cocok.
+func typeAccepts(declared, actual string) bool {
+	if actual == "" {
+		return true
+	}
+	switch declared {
+	case "", "mixed":
+		return true
+	case "float":
+		return actual == "float" || actual == "int"
+	case "iterable":
+		return actual == "array" || actual == "generator"
+	case "callable":
+		return actual == "callable" || actual == "string"
+	}
+	return declared == actual
+}
+
+func checkType(declared string, val interface{}, what string) {
+	if actual := typeOf(val); !typeAccepts(declared, actual) {
+		panic(fmt.Sprintf("type error: %s harus bertipe %s, dapat %s", what, declared, actual))
+	}
+}
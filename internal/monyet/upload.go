@@ -0,0 +1,240 @@
+package monyet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Batas default upload, bisa diganti lewat config (nama key sama seperti
+// php.ini): upload_max_filesize per file, post_max_size per request.
+const (
+	defaultUploadMaxFilesize = 16 << 20
+	defaultPostMaxSize       = 64 << 20
+)
+
+// Kode error $_FILES[...]["error"], sama seperti PHP.
+const (
+	uploadErrOK        = 0
+	uploadErrIniSize   = 1
+	uploadErrPartial   = 3
+	uploadErrNoFile    = 4
+	uploadErrCantWrite = 7
+)
+
+func init() {
+	predefinedConsts["UPLOAD_ERR_OK"] = float64(uploadErrOK)
+	predefinedConsts["UPLOAD_ERR_INI_SIZE"] = float64(uploadErrIniSize)
+	predefinedConsts["UPLOAD_ERR_PARTIAL"] = float64(uploadErrPartial)
+	predefinedConsts["UPLOAD_ERR_NO_FILE"] = float64(uploadErrNoFile)
+	predefinedConsts["UPLOAD_ERR_CANT_WRITE"] = float64(uploadErrCantWrite)
+
+	RegisterBuiltin(Builtin{Name: "move_uploaded_file", MinArgs: 2, MaxArgs: 2, Params: []string{"string", "string"}, Returns: "bool", Fn: builtinMoveUploadedFile})
+	RegisterBuiltin(Builtin{Name: "is_uploaded_file", MinArgs: 1, MaxArgs: 1, Params: []string{"string"}, Returns: "bool", Fn: builtinIsUploadedFile})
+}
+
+// parseSize membaca ukuran seperti "10M", "512K", "1G" atau angka byte.
+func parseSize(s string, def int64) int64 {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" {
+		return def
+	}
+	mult := int64(1)
+	switch s[len(s)-1] {
+	case 'K':
+		mult = 1 << 10
+	case 'M':
+		mult = 1 << 20
+	case 'G':
+		mult = 1 << 30
+	}
+	if mult > 1 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n * mult
+}
+
+func postMaxSize(env *Env) int64 {
+	return parseSize(configString(env, "post_max_size"), defaultPostMaxSize)
+}
+
+// parseMultipart membaca body multipart secara streaming. Field biasa masuk
+// ke map fields, file langsung ditulis ke file sementara (tidak ditampung
+// di RAM) dan dicatat di ctx supaya dihapus setelah handler selesai.
+func parseMultipart(env *Env, r *http.Request) (fields, files map[string]interface{}, err error) {
+	ctx := env.req
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, nil, err
+	}
+	maxFile := parseSize(configString(env, "upload_max_filesize"), defaultUploadMaxFilesize)
+	tmpDir := configString(env, "upload_tmp_dir")
+
+	values := map[string][]string{}
+	fileList := map[string][]interface{}{}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		name := part.FormName()
+		if name == "" {
+			part.Close()
+			continue
+		}
+		if !isFilePart(part) {
+			b, err := io.ReadAll(part)
+			part.Close()
+			if err != nil {
+				return nil, nil, err
+			}
+			values[name] = append(values[name], string(b))
+			continue
+		}
+
+		info, err := saveUploadPart(ctx, part, tmpDir, maxFile)
+		part.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+		fileList[name] = append(fileList[name], info)
+	}
+
+	fields = formValues(values)
+	files = map[string]interface{}{}
+	for key, list := range fileList {
+		name, isList := strings.CutSuffix(key, "[]")
+		if len(list) == 1 && !isList {
+			files[name] = list[0]
+		} else {
+			files[name] = list
+		}
+	}
+	return fields, files, nil
+}
+
+// isFilePart: part dengan parameter filename adalah file, termasuk
+// filename="" yang dikirim browser untuk input file yang tidak diisi.
+func isFilePart(part *multipart.Part) bool {
+	_, params, err := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+	_, ok := params["filename"]
+	return err == nil && ok
+}
+
+// saveUploadPart menyalin satu file ke file sementara. File yang melebihi
+// batas tidak membatalkan request, cukup ditandai UPLOAD_ERR_INI_SIZE.
+// Error dari body request (misalnya melewati post_max_size) dikembalikan.
+func saveUploadPart(ctx *requestContext, part *multipart.Part, tmpDir string, maxFile int64) (map[string]interface{}, error) {
+	info := map[string]interface{}{
+		"name":     part.FileName(),
+		"type":     part.Header.Get("Content-Type"),
+		"size":     float64(0),
+		"tmp_name": "",
+		"error":    float64(uploadErrOK),
+	}
+	if info["type"] == "" {
+		info["type"] = "application/octet-stream"
+	}
+	if part.FileName() == "" {
+		info["type"] = ""
+		info["error"] = float64(uploadErrNoFile)
+		_, err := io.Copy(io.Discard, part)
+		return info, err
+	}
+
+	tmp, err := os.CreateTemp(tmpDir, "monyet-upload-*")
+	if err != nil {
+		info["error"] = float64(uploadErrCantWrite)
+		_, err = io.Copy(io.Discard, part)
+		return info, err
+	}
+	ctx.uploads = append(ctx.uploads, tmp.Name())
+
+	// Baca satu byte lebih dari batas untuk tahu apakah file kebesaran
+	n, err := io.Copy(tmp, io.LimitReader(part, maxFile+1))
+	tmp.Close()
+	if err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			return nil, err
+		}
+		info["error"] = float64(uploadErrPartial)
+		return info, nil
+	}
+	if n > maxFile {
+		os.Remove(tmp.Name())
+		info["error"] = float64(uploadErrIniSize)
+		_, err = io.Copy(io.Discard, part)
+		return info, err
+	}
+	info["size"] = float64(n)
+	info["tmp_name"] = tmp.Name()
+	return info, nil
+}
+
+// cleanupUploads menghapus file sementara yang tidak dipindahkan handler.
+func (ctx *requestContext) cleanupUploads() {
+	for _, path := range ctx.uploads {
+		os.Remove(path)
+	}
+	ctx.uploads = nil
+}
+
+func isUpload(ctx *requestContext, path string) bool {
+	for _, p := range ctx.uploads {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+func builtinIsUploadedFile(env *Env, args []interface{}) interface{} {
+	return isUpload(currentRequest(env, "is_uploaded_file"), args[0].(string))
+}
+
+// builtinMoveUploadedFile: move_uploaded_file($f["tmp_name"], "uploads/a.jpg").
+// Sumber harus file upload dari request ini, tujuan mengikuti root
+// filesystem script seperti fungsi file lainnya.
+func builtinMoveUploadedFile(env *Env, args []interface{}) interface{} {
+	ctx := currentRequest(env, "move_uploaded_file")
+	src := args[0].(string)
+	if !isUpload(ctx, src) {
+		return fsWarn("move_uploaded_file", fmt.Errorf("%s bukan file upload dari request ini", src))
+	}
+	root, name := fsPath(env, args[1].(string))
+
+	in, err := os.Open(src)
+	if err != nil {
+		return fsWarn("move_uploaded_file", err)
+	}
+	defer in.Close()
+	out, err := root.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, defaultFilePermissions)
+	if err != nil {
+		return fsWarn("move_uploaded_file", err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		root.Remove(name)
+		return fsWarn("move_uploaded_file", err)
+	}
+	if err := out.Close(); err != nil {
+		return fsWarn("move_uploaded_file", err)
+	}
+	os.Remove(src)
+	return true
+}
@@ -0,0 +1,180 @@
+package monyet
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const uploadScript = `
+use("recover");
+route("POST", "/upload", function() {
+    $f = _FILES["foto"];
+    return json_encode($f);
+});
+route("POST", "/simpan", function() {
+    $f = _FILES["foto"];
+    $ok = move_uploaded_file($f["tmp_name"], _POST["tujuan"]);
+    return json_encode($ok);
+});
+`
+
+// uploadHandler menyiapkan router dengan root filesystem dan folder temp
+// upload sendiri, plus setting tambahan seperti "upload_max_filesize=1K".
+func uploadHandler(t *testing.T, settings ...string) (http.Handler, string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	root, tmp := filepath.Join(dir, "app"), filepath.Join(dir, "tmp")
+	os.Mkdir(root, 0755)
+	os.Mkdir(tmp, 0755)
+	cfg, err := LoadConfig(root, append([]string{"upload_tmp_dir=" + tmp}, settings...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := NewEnv()
+	env.SetVar("__BASE_DIR__", root)
+	env.SetConfig(cfg)
+	Eval(NewParser(NewLexer(uploadScript)).Parse(), env)
+	router := routerOf(env)
+	return wrapHTTP(router.middleware, router.mux), root, tmp
+}
+
+// multipartRequest membuat request dengan satu file "foto" dan field biasa.
+func multipartRequest(t *testing.T, path string, file []byte, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	fw, err := mw.CreateFormFile("foto", "kucing.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(file)
+	mw.Close()
+	req := httptest.NewRequest("POST", path, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func send(h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func tmpFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestUploadFile(t *testing.T) {
+	h, _, tmp := uploadHandler(t)
+	rec := send(h, multipartRequest(t, "/upload", []byte("isi foto"), nil))
+	body := rec.Body.String()
+	if rec.Code != 200 || !strings.Contains(body, `"error":0`) || !strings.Contains(body, `"size":8`) || !strings.Contains(body, `"name":"kucing.jpg"`) {
+		t.Fatalf("upload = %d %s", rec.Code, body)
+	}
+	if !strings.Contains(body, tmp) {
+		t.Errorf("tmp_name tidak di upload_tmp_dir %s: %s", tmp, body)
+	}
+	// file sementara dihapus setelah handler selesai
+	if left := tmpFiles(t, tmp); len(left) > 0 {
+		t.Errorf("file sementara tertinggal: %v", left)
+	}
+}
+
+func TestUploadTooLargeFile(t *testing.T) {
+	h, _, tmp := uploadHandler(t, "upload_max_filesize=1K")
+	rec := send(h, multipartRequest(t, "/upload", bytes.Repeat([]byte("x"), 2048), nil))
+	body := rec.Body.String()
+	if rec.Code != 200 || !strings.Contains(body, `"error":1`) || !strings.Contains(body, `"tmp_name":""`) {
+		t.Fatalf("file kebesaran = %d %s, want error UPLOAD_ERR_INI_SIZE", rec.Code, body)
+	}
+	if left := tmpFiles(t, tmp); len(left) > 0 {
+		t.Errorf("file sementara tertinggal: %v", left)
+	}
+
+	// tepat di batas masih diterima
+	rec = send(h, multipartRequest(t, "/upload", bytes.Repeat([]byte("x"), 1024), nil))
+	if !strings.Contains(rec.Body.String(), `"error":0`) {
+		t.Errorf("file 1K = %s, want UPLOAD_ERR_OK", rec.Body.String())
+	}
+}
+
+func TestUploadTooLargeBody(t *testing.T) {
+	h, _, tmp := uploadHandler(t, "post_max_size=1K")
+	rec := send(h, multipartRequest(t, "/upload", bytes.Repeat([]byte("x"), 4096), nil))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("body kebesaran = %d %s, want 413", rec.Code, rec.Body.String())
+	}
+	if left := tmpFiles(t, tmp); len(left) > 0 {
+		t.Errorf("file sementara tertinggal: %v", left)
+	}
+
+	// batas yang sama berlaku untuk body biasa
+	req := httptest.NewRequest("POST", "/upload", strings.NewReader(strings.Repeat("a=1&", 1000)))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if rec := send(h, req); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("form kebesaran = %d, want 413", rec.Code)
+	}
+}
+
+func TestMoveUploadedFile(t *testing.T) {
+	h, root, tmp := uploadHandler(t)
+	os.Mkdir(filepath.Join(root, "uploads"), 0755)
+
+	rec := send(h, multipartRequest(t, "/simpan", []byte("isi foto"), map[string]string{"tujuan": "uploads/a.jpg"}))
+	if rec.Body.String() != "true" {
+		t.Fatalf("move_uploaded_file = %s, want true", rec.Body.String())
+	}
+	if b, err := os.ReadFile(filepath.Join(root, "uploads", "a.jpg")); err != nil || string(b) != "isi foto" {
+		t.Errorf("file tujuan = %q, %v", b, err)
+	}
+	if left := tmpFiles(t, tmp); len(left) > 0 {
+		t.Errorf("file sementara tertinggal: %v", left)
+	}
+
+	// tujuan di luar root filesystem ditolak
+	outside := filepath.Join(filepath.Dir(root), "luar.jpg")
+	for _, dest := range []string{"../luar.jpg", outside} {
+		rec := send(h, multipartRequest(t, "/simpan", []byte("jahat"), map[string]string{"tujuan": dest}))
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("move_uploaded_file ke %s = %d %s, want akses ditolak", dest, rec.Code, rec.Body.String())
+		}
+		if _, err := os.Stat(outside); err == nil {
+			t.Fatalf("file tertulis di luar root lewat %s", dest)
+		}
+	}
+	if left := tmpFiles(t, tmp); len(left) > 0 {
+		t.Errorf("file sementara tertinggal setelah handler error: %v", left)
+	}
+}
+
+func TestMoveUploadedFileRejectsOtherFiles(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "bukan-upload.txt"), []byte("x"), 0644)
+	env := NewEnv()
+	env.req = &requestContext{}
+	env.SetVar("__BASE_DIR__", root)
+	if got, _ := tryEval(env, `move_uploaded_file("`+filepath.Join(root, "bukan-upload.txt")+`", "b.txt")`); got != false {
+		t.Errorf("move_uploaded_file file biasa = %#v, want false", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, "b.txt")); err == nil {
+		t.Error("file biasa ikut dipindahkan")
+	}
+}